@@ -144,7 +144,7 @@ func (d *Display) Update() error {
 	}
 
 	for i, textLine := range d.buffer {
-		screen.Dot = fixed.P(0, d.lineHeight*(1+i) - d.font.Metrics().Descent.Round())
+		screen.Dot = fixed.P(0, d.baseline(i))
 		screen.DrawString(textLine)
 	}
 	if err := d.driver.Draw(d.driver.Bounds(), img, image.Point{}); err != nil {
@@ -154,6 +154,12 @@ func (d *Display) Update() error {
 	return nil
 }
 
+// baseline returns the y coordinate of the baseline of the given text line,
+// raised by the font's descent so that descenders stay within the line.
+func (d *Display) baseline(line int) int {
+	return d.lineHeight*(1+line) - d.font.Metrics().Descent.Round()
+}
+
 func (d *Display) SetFont(f font.Face) {
 	d.font = f
 	d.lineHeight = f.Metrics().Height.Ceil()
@@ -10,6 +10,7 @@ import (
 	"github.com/larsks/display1306/display/fakedriver"
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/basicfont"
+	"periph.io/x/devices/v3/ssd1306/image1bit"
 )
 
 // Call represents a method call on the mock
@@ -551,6 +552,35 @@ func TestDisplay_Update(t *testing.T) {
 	}
 }
 
+func TestDisplay_Baseline(t *testing.T) {
+	mock := NewTrackedFakeSSD1306()
+	display, err := NewDisplay().WithDriver(mock).Build()
+	assertNoError(t, err)
+	assertNoError(t, display.Init())
+
+	descent := display.font.Metrics().Descent.Round()
+	if got, want := display.baseline(0), display.lineHeight-descent; got != want {
+		t.Errorf("Expected line 0 baseline at %d, got %d", want, got)
+	}
+
+	// The descender of a glyph on line 0 must stay within that line.
+	display.buffer[0] = "g"
+	assertNoError(t, display.Update())
+	_, src, _ := mock.LastDrawArgs()
+	lowest := -1
+	b := src.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if src.At(x, y) == image1bit.On {
+				lowest = y
+			}
+		}
+	}
+	if lowest <= display.baseline(0) || lowest >= display.lineHeight {
+		t.Errorf("Expected the descender to end between the baseline and %d, got %d", display.lineHeight, lowest)
+	}
+}
+
 func TestDisplay_MethodsFailWithoutInit(t *testing.T) {
 	mock := NewTrackedFakeSSD1306()
 	display, err := NewDisplay().WithBusName("/dev/i2c-0").WithDriver(mock).Build()
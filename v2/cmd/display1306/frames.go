@@ -0,0 +1,27 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// frameInterval returns how long to show an image before moving on to the
+// next one. A duration may be given at the end of the file name, as in
+// "foo@2s.png"; otherwise, or if the suffix is not a valid duration, the
+// fallback is used.
+func frameInterval(path string, fallback time.Duration) time.Duration {
+	name := filepath.Base(path)
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+
+	i := strings.LastIndex(name, "@")
+	if i < 0 {
+		return fallback
+	}
+
+	interval, err := time.ParseDuration(name[i+1:])
+	if err != nil || interval < 0 {
+		return fallback
+	}
+	return interval
+}
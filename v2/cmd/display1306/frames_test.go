@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFrameInterval(t *testing.T) {
+	const fallback = 30 * time.Millisecond
+
+	tests := []struct {
+		path string
+		want time.Duration
+	}{
+		{"foo@2s.png", 2 * time.Second},
+		{"images/intro@1m30s.gif", 90 * time.Second},
+		{"some@dir/bar@250ms.png", 250 * time.Millisecond},
+		{"foo.png", fallback},
+		{"foo@soon.png", fallback},
+		{"foo@.png", fallback},
+		{"foo@-1s.png", fallback},
+		{"some@2s/foo.png", fallback},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := frameInterval(tt.path, fallback); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -2,9 +2,12 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"fmt"
+	"image"
 	"log"
 	"os"
+	"runtime"
 	"strings"
 	"syscall"
 	"time"
@@ -25,9 +28,13 @@ type (
 		FontSize      float64
 		Image         bool
 		ImageInterval time.Duration
+		Prefetch      int
 		Loop          bool
 		Duration      time.Duration
 		Wait          bool
+		KeepOpen      bool
+		TestPattern   string
+		Template      string
 	}
 )
 
@@ -84,9 +91,13 @@ func init() {
 	pflag.StringVarP(&options.Font, "font", "f", "", "path to truetype font file")
 	pflag.Float64VarP(&options.FontSize, "font-size", "s", 13.0, "font size in points (ignored if --font not provided)")
 	pflag.BoolVarP(&options.Image, "image", "i", false, "interpret non-option arguments as image filenames")
-	pflag.DurationVar(&options.ImageInterval, "image-interval", 30*time.Millisecond, "interval between images")
+	pflag.DurationVar(&options.ImageInterval, "image-interval", 30*time.Millisecond, "interval between images (override per image with a name like foo@2s.png)")
+	pflag.IntVar(&options.Prefetch, "prefetch", 2, "number of images to decode ahead of the one on display")
 	pflag.BoolVar(&options.Loop, "loop", false, "loop through images continuously")
 	pflag.BoolVar(&options.Wait, "wait", false, "pause and require ctrl-c to exit")
+	pflag.BoolVar(&options.KeepOpen, "keep-open", false, "keep serving the final frame after rendering until ctrl-c")
+	pflag.StringVar(&options.TestPattern, "test-pattern", "", "show a test pattern (checker|on|off|stripes|vstripes)")
+	pflag.StringVar(&options.Template, "template", "", "render lines from a text/template file using .Env, .Time and .Hostname")
 	pflag.DurationVar(&options.Duration, "duration", 0, "maximum duration to run loop (0 for unlimited)")
 }
 
@@ -105,18 +116,30 @@ func main() {
 			if !options.Image {
 				log.Fatalf("--loop can only be used with --image")
 			}
-		case "image-interval":
+		case "image-interval", "prefetch":
 			if !options.Image {
-				log.Fatalf("--image-interval can only be used with --image")
+				log.Fatalf("--%s can only be used with --image", f.Name)
 			}
 		case "font-size", "font":
 			if options.Image {
 				log.Fatalf("--font and --font-size cannot be used with --image")
 			}
+		case "template":
+			if options.Image || options.TestPattern != "" || len(args) > 0 {
+				log.Fatalf("--template cannot be used with --image, --test-pattern or text arguments")
+			}
+		case "test-pattern":
+			if options.Image || len(args) > 0 {
+				log.Fatalf("--test-pattern cannot be used with --image or text arguments")
+			}
 		case "wait":
 			if !options.DryRun {
 				log.Fatalf("--wait can only be used with --dry-run")
 			}
+		case "keep-open":
+			if !options.DryRun {
+				log.Fatalf("--keep-open can only be used with --dry-run")
+			}
 		}
 	})
 
@@ -124,8 +147,18 @@ func main() {
 	// This has to happen before calling d.Init(), otherwise we get errors
 	// reading from stdin.
 	var lines []string
-	if !options.Image {
-		if len(args) > 0 {
+	if !options.Image && options.TestPattern == "" {
+		if options.Template != "" {
+			text, err := os.ReadFile(options.Template)
+			if err != nil {
+				log.Fatalf("failed to read template: %v", err)
+			}
+			hostname, _ := os.Hostname()
+			lines, err = renderTemplate(string(text), os.Environ(), time.Now(), hostname)
+			if err != nil {
+				log.Fatal(err)
+			}
+		} else if len(args) > 0 {
 			lines = args
 		} else {
 			scanner := bufio.NewScanner(os.Stdin)
@@ -143,13 +176,17 @@ func main() {
 	if options.DryRun {
 		fakeDriver = fakedriver.NewFakeSSD1306()
 		fakeDriver.SetWaitMode(true)
+		fakeDriver.WithPersistAfterClose(options.KeepOpen)
 		driver = fakeDriver
 	}
 
 	// Initialize display
+	// Each run starts with whatever a previous run left on the panel, so
+	// always draw, even if there is no text.
 	builder := display.NewDisplay().
 		WithBusName(options.Device).
-		WithDriver(driver)
+		WithDriver(driver).
+		WithAlwaysDraw(true)
 
 	if options.Font != "" {
 		fontData, err := os.ReadFile(options.Font)
@@ -162,12 +199,7 @@ func main() {
 			log.Fatalf("failed to parse font: %v", err)
 		}
 
-		fontFace := truetype.NewFace(tf, &truetype.Options{
-			Size: options.FontSize,
-			DPI:  72,
-		})
-
-		builder = builder.WithFont(fontFace)
+		builder = builder.WithTrueTypeFont(tf, options.FontSize)
 	}
 
 	d, err := builder.Build()
@@ -191,15 +223,54 @@ func main() {
 		log.Println("Start button clicked, beginning rendering...")
 	}
 
-	if options.Image {
+	if options.TestPattern != "" {
+		kind, err := display.ParsePatternKind(options.TestPattern)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := d.TestPattern(kind); err != nil {
+			log.Fatal(err)
+		}
+	} else if options.Image {
 		// Display images in sequence
 		var startTime time.Time
 		if options.Loop && options.Duration > 0 {
 			startTime = time.Now()
 		}
 
+		var imagePaths []string
+		for _, arg := range args {
+			if arg[0] != '@' {
+				imagePaths = append(imagePaths, arg)
+			}
+		}
+
+		// Decode and convert upcoming images in the background so that
+		// showing each one is just a draw.
+		load := func(path string) ([]byte, error) {
+			file, err := os.Open(path)
+			if err != nil {
+				return nil, err
+			}
+			defer file.Close() //nolint:errcheck
+
+			img, _, err := image.Decode(file)
+			if err != nil {
+				return nil, err
+			}
+			frame, err := d.ConvertImage(img)
+			if err != nil {
+				return nil, err
+			}
+			return frame.Pix, nil
+		}
+		workers := min(max(options.Prefetch, 1), runtime.NumCPU())
+
 	outer:
 		for {
+			ctx, cancel := context.WithCancel(context.Background())
+			frames := prefetch(ctx, imagePaths, workers, options.Prefetch, load)
+
 			for _, imagePath := range args {
 				if imagePath[0] == '@' {
 					skip, err := processCommand(imagePath, d)
@@ -210,20 +281,26 @@ func main() {
 						continue
 					}
 				} else {
-					if err := d.ShowImageFromFile(imagePath); err != nil {
-						log.Fatalf("failed to display image %s: %v", imagePath, err)
+					frame := <-frames
+					if frame.err != nil {
+						log.Fatalf("failed to display image %s: %v", frame.path, frame.err)
+					}
+					if err := d.ShowRaw(frame.frame); err != nil {
+						log.Fatalf("failed to display image %s: %v", frame.path, err)
 					}
 				}
 
 				if len(args) > 1 {
-					time.Sleep(options.ImageInterval)
+					time.Sleep(frameInterval(imagePath, options.ImageInterval))
 				}
 
 				// Check duration limit if looping
 				if options.Loop && options.Duration > 0 && time.Since(startTime) >= options.Duration {
+					cancel()
 					break outer
 				}
 			}
+			cancel()
 			if !options.Loop {
 				break
 			}
@@ -251,5 +328,10 @@ func main() {
 	if fakeDriver != nil {
 		// Explicitly close the display to shut down the HTTP server
 		d.Close() //nolint:errcheck
+
+		if options.KeepOpen {
+			log.Printf("serving final frame; press CTRL-C to exit")
+			syscall.Pause() //nolint:errcheck
+		}
 	}
 }
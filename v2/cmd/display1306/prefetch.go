@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+)
+
+type (
+	// prefetched is the result of loading one image ahead of time.
+	prefetched struct {
+		path  string
+		frame []byte
+		err   error
+	}
+
+	// prefetchJob asks a worker to load path and deliver the result on
+	// done.
+	prefetchJob struct {
+		path string
+		done chan prefetched
+	}
+)
+
+// prefetch loads paths with load on a pool of workers, keeping up to ahead
+// results ready beyond the one being delivered, and returns them in the
+// order of paths. This lets a slideshow decode and convert the next images
+// while the current one is on display. The returned channel is closed once
+// every path has been delivered or ctx is cancelled.
+func prefetch(ctx context.Context, paths []string, workers, ahead int, load func(path string) ([]byte, error)) <-chan prefetched {
+	jobs := make(chan prefetchJob)
+	pending := make(chan chan prefetched, max(ahead, 0))
+	out := make(chan prefetched)
+
+	// Queue the paths in order, reserving a place in pending for each
+	// before handing it to a worker so that results can be put back in
+	// order.
+	go func() {
+		defer close(jobs)
+		defer close(pending)
+		for _, path := range paths {
+			done := make(chan prefetched, 1)
+			select {
+			case pending <- done:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- prefetchJob{path: path, done: done}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for range max(workers, 1) {
+		go func() {
+			for job := range jobs {
+				frame, err := load(job.path)
+				job.done <- prefetched{path: job.path, frame: frame, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(out)
+		for done := range pending {
+			var result prefetched
+			select {
+			case result = <-done:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case out <- result:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
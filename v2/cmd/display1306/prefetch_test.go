@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+func TestPrefetch(t *testing.T) {
+	var paths []string
+	for i := range 8 {
+		paths = append(paths, fmt.Sprintf("frame%d.png", i))
+	}
+
+	// Loads take a random time so that workers finish out of order.
+	load := func(path string) ([]byte, error) {
+		time.Sleep(time.Duration(rand.IntN(10)) * time.Millisecond)
+		return []byte(path), nil
+	}
+
+	var got []string
+	for frame := range prefetch(context.Background(), paths, 4, 2, load) {
+		if frame.err != nil {
+			t.Fatalf("Unexpected error for %s: %v", frame.path, frame.err)
+		}
+		got = append(got, string(frame.frame))
+	}
+
+	if fmt.Sprint(got) != fmt.Sprint(paths) {
+		t.Errorf("Expected frames in order %v, got %v", paths, got)
+	}
+}
+
+func TestPrefetch_OverlapsDisplay(t *testing.T) {
+	const (
+		decode  = 20 * time.Millisecond
+		display = 40 * time.Millisecond
+	)
+	paths := []string{"a", "b", "c", "d"}
+	load := func(path string) ([]byte, error) {
+		time.Sleep(decode)
+		return []byte(path), nil
+	}
+
+	frames := prefetch(context.Background(), paths, 1, 1, load)
+	<-frames
+	for _, path := range paths[1:] {
+		// While the previous frame is on display, the next one is
+		// decoded, so it is ready as soon as it is wanted.
+		time.Sleep(display)
+		start := time.Now()
+		<-frames
+		if wait := time.Since(start); wait > decode/2 {
+			t.Errorf("Expected %s to be decoded during the previous display, waited %v", path, wait)
+		}
+	}
+}
+
+func TestPrefetch_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	frames := prefetch(ctx, []string{"a", "b", "c"}, 1, 1, func(path string) ([]byte, error) {
+		return []byte(path), nil
+	})
+
+	<-frames
+	cancel()
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-frames:
+			if !ok {
+				return
+			}
+		case <-deadline:
+			t.Fatal("Expected the frame channel to be closed after cancellation")
+		}
+	}
+}
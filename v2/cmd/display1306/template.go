@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// templateData is the data available to a --template file: environment
+// variables as .Env.NAME, the current time as .Time and the host name as
+// .Hostname.
+type templateData struct {
+	Env      map[string]string
+	Time     time.Time
+	Hostname string
+}
+
+// renderTemplate executes a text/template and returns the lines it
+// produces. env holds "NAME=value" entries as returned by os.Environ.
+func renderTemplate(text string, env []string, now time.Time, hostname string) ([]string, error) {
+	tmpl, err := template.New("display").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	data := templateData{
+		Env:      make(map[string]string, len(env)),
+		Time:     now,
+		Hostname: hostname,
+	}
+	for _, kv := range env {
+		if name, value, ok := strings.Cut(kv, "="); ok {
+			data.Env[name] = value
+		}
+	}
+
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+
+	return strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n"), nil
+}
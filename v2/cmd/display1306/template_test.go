@@ -0,0 +1,26 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	text := "{{.Hostname}}\nload {{.Env.LOAD}}\n{{.Time.Format \"15:04\"}}{{.Env.MISSING}}\n"
+
+	lines, err := renderTemplate(text, []string{"LOAD=0.42", "EMPTY="}, now, "pi")
+	if err != nil {
+		t.Fatalf("renderTemplate failed: %v", err)
+	}
+
+	want := []string{"pi", "load 0.42", "07:08"}
+	if strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Errorf("Expected lines %q, got %q", want, lines)
+	}
+
+	if _, err := renderTemplate("{{.Nope", nil, now, "pi"); err == nil {
+		t.Error("Expected an error for an invalid template")
+	}
+}
@@ -0,0 +1,140 @@
+package display
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"log"
+	"time"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+	"periph.io/x/devices/v3/ssd1306/image1bit"
+)
+
+// WithAlertBlink makes the alert set with SetAlert flash on and off while
+// its condition holds, instead of showing steadily.
+func (d *Display) WithAlertBlink(blink bool) *Display {
+	d.alertBlink = blink
+	return d
+}
+
+// SetAlert registers lines to show in place of the current content whenever
+// cond returns true. A background check calls cond periodically; when it
+// becomes true the alert covers the panel, and when it becomes false again
+// the panel goes back to whatever was last drawn. Draws made while the alert
+// is showing are kept for the restore rather than sent to the panel. The
+// alert is drawn with the same line colors and background as other text. A
+// nil cond removes the alert. The background check starts with the first
+// alert set and runs until Close.
+func (d *Display) SetAlert(cond func() bool, alertLines []string) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	if len(alertLines) > int(d.lines) {
+		return fmt.Errorf("alert has %d lines but display only has %d", len(alertLines), d.lines)
+	}
+
+	frame := d.renderLines(alertLines)
+
+	d.alertMutex.Lock()
+	defer d.alertMutex.Unlock()
+	d.alertCond = cond
+	d.alertFrame = frame
+	if cond != nil && !d.alertRunning {
+		d.alertRunning = true
+		d.goBackground(d.backgroundCtx, d.runAlerts)
+	}
+	return nil
+}
+
+// renderLines draws lines of text in the default font on an otherwise
+// empty frame, laid out and colored like the text buffer.
+func (d *Display) renderLines(lines []string) *image1bit.VerticalLSB {
+	img := d.backgroundFrame()
+	area := d.textArea()
+	screen := font.Drawer{
+		Dst:  clippedImage{Image: img, clip: area},
+		Src:  &image.Uniform{image1bit.On},
+		Face: d.font,
+	}
+
+	for i := range int(d.lines) {
+		fg, bg := d.lineColors(i)
+		if bg != image1bit.Bit(d.invertedBackground) {
+			fillRect(img, d.lineRect(i), bg)
+		}
+		if i >= len(lines) {
+			continue
+		}
+
+		screen.Src = &image.Uniform{fg}
+		screen.Dot = fixed.P(area.Min.X, area.Min.Y+d.baseline(i))
+		d.drawString(&screen, d.replaceMissingGlyphs(d.font, d.cleanLine(lines[i])))
+	}
+
+	if d.screenBorder {
+		strokeRect(img, img.Bounds(), image1bit.Bit(!d.invertedBackground))
+	}
+	return img
+}
+
+// backgroundFrame returns a frame showing only the background, which is lit
+// with WithInvertedBackground.
+func (d *Display) backgroundFrame() *image1bit.VerticalLSB {
+	img := image1bit.NewVerticalLSB(d.driver.Bounds())
+	if d.invertedBackground {
+		fillRect(img, img.Bounds(), image1bit.On)
+	}
+	return img
+}
+
+// runAlerts checks the alert condition set with SetAlert once per alert
+// interval, covering the panel with the alert while it holds and restoring
+// the last drawn frame once it clears.
+func (d *Display) runAlerts(ctx context.Context) {
+	ticker := time.NewTicker(d.alertInterval)
+	defer ticker.Stop()
+
+	blank := d.backgroundFrame()
+	lit := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		d.alertMutex.Lock()
+		cond, alert := d.alertCond, d.alertFrame
+		d.alertMutex.Unlock()
+		active := cond != nil && cond()
+
+		d.mutex.Lock()
+		var frame image.Image
+		switch {
+		case active && (!d.alerting || d.alertBlink):
+			frame = alert
+			if d.alerting && lit {
+				frame = blank
+			}
+			lit = frame == alert
+			d.alerting = true
+		case !active && d.alerting:
+			frame = blank
+			if d.shown != nil {
+				frame = d.shown
+			}
+			d.alerting = false
+		}
+		if frame != nil {
+			d.haveLastFrame = false
+			d.blank = blankFrame(frame)
+			if err := d.drawLocked(frame); err != nil {
+				log.Printf("alert failed to draw: %v", err)
+			}
+		}
+		d.mutex.Unlock()
+	}
+}
@@ -0,0 +1,131 @@
+package display
+
+import (
+	"bytes"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"periph.io/x/devices/v3/ssd1306/image1bit"
+)
+
+// waitForFrame waits for the last frame drawn on mock to equal want.
+func waitForFrame(t *testing.T, mock *TrackedFakeSSD1306, want *image1bit.VerticalLSB) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		_, src, _ := mock.LastDrawArgs()
+		if frame, ok := src.(*image1bit.VerticalLSB); ok && bytes.Equal(frame.Pix, want.Pix) {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("Timed out waiting for the expected frame")
+}
+
+func TestDisplay_SetAlert(t *testing.T) {
+	builder := newTestBuilder()
+	builder.alertInterval = 5 * time.Millisecond
+	display, mock := newTestDisplay(t, builder)
+	defer display.Close() //nolint:errcheck
+
+	assertNoError(t, display.PrintLine(0, "all good"))
+	assertNoError(t, display.Update())
+
+	var failing atomic.Bool
+	assertNoError(t, display.SetAlert(failing.Load, []string{"DISK FULL"}))
+	alert := display.renderLines([]string{"DISK FULL"})
+
+	failing.Store(true)
+	waitForFrame(t, mock, alert)
+
+	// Updates made during the alert are held back and shown on restore.
+	draws := mock.CallCount("Draw")
+	assertNoError(t, display.PrintLine(1, "cleaning up"))
+	assertNoError(t, display.Update())
+	if got := mock.CallCount("Draw"); got != draws {
+		t.Errorf("Expected no draws while the alert is showing, got %d", got-draws)
+	}
+
+	failing.Store(false)
+	waitForFrame(t, mock, display.render())
+
+	_, src, _ := mock.LastDrawArgs()
+	if countOn(src, display.lineRect(1)) == 0 {
+		t.Error("Expected the restored frame to include the update made during the alert")
+	}
+}
+
+func TestDisplay_SetAlert_Blink(t *testing.T) {
+	builder := newTestBuilder().WithAlertBlink(true)
+	builder.alertInterval = 5 * time.Millisecond
+	display, mock := newTestDisplay(t, builder)
+	defer display.Close() //nolint:errcheck
+
+	assertNoError(t, display.SetAlert(func() bool { return true }, []string{"ALERT"}))
+	time.Sleep(50 * time.Millisecond)
+
+	var lit, dark int
+	for _, img := range mock.DrawnImages() {
+		if countOn(img, img.Bounds()) > 0 {
+			lit++
+		} else {
+			dark++
+		}
+	}
+	if lit == 0 || dark == 0 {
+		t.Errorf("Expected the alert to blink, got %d lit and %d dark frames", lit, dark)
+	}
+
+	assertError(t, display.SetAlert(nil, make([]string, 6)), "only has 5")
+}
+
+func TestDisplay_SetAlert_StartsCheckLazily(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder())
+	defer display.Close() //nolint:errcheck
+
+	assertNoError(t, display.SetAlert(nil, nil))
+	if display.alertRunning {
+		t.Error("Expected no alert check without an alert")
+	}
+
+	assertNoError(t, display.SetAlert(func() bool { return false }, []string{"ALERT"}))
+	if !display.alertRunning {
+		t.Error("Expected the first alert to start the alert check")
+	}
+}
+
+func TestDisplay_SetAlert_Colors(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder().WithInvertedBackground(true).WithScreenBorder(true))
+	defer display.Close() //nolint:errcheck
+
+	assertNoError(t, display.SetLineBackground(1, false))
+	assertNoError(t, display.PrintLines(0, []string{"DISK", "FULL"}))
+
+	// The alert looks exactly like the same lines printed normally.
+	alert := display.renderLines([]string{"DISK", "FULL"})
+	if !bytes.Equal(alert.Pix, display.render().Pix) {
+		t.Error("Expected the alert to be drawn with the display's colors")
+	}
+}
+
+func TestDisplay_SetAlert_Screensaver(t *testing.T) {
+	builder := newTestBuilder().WithScreensaver(NewTestImage(8, 8), 30*time.Millisecond)
+	builder.screensaverInterval = 5 * time.Millisecond
+	builder.alertInterval = 5 * time.Millisecond
+	display, mock := newTestDisplay(t, builder)
+	defer display.Close() //nolint:errcheck
+
+	assertNoError(t, display.Update())
+	assertNoError(t, display.SetAlert(func() bool { return true }, []string{"ALERT"}))
+	alert := display.renderLines([]string{"ALERT"})
+	waitForFrame(t, mock, alert)
+
+	// Nothing is drawn while the alert shows, but the screensaver must not
+	// take over.
+	time.Sleep(80 * time.Millisecond)
+	_, src, _ := mock.LastDrawArgs()
+	if frame, ok := src.(*image1bit.VerticalLSB); !ok || !bytes.Equal(frame.Pix, alert.Pix) {
+		t.Error("Expected the alert to stay on the panel past the screensaver idle period")
+	}
+}
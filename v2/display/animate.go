@@ -0,0 +1,427 @@
+package display
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"log"
+	"math"
+	"time"
+
+	"periph.io/x/devices/v3/ssd1306"
+	"periph.io/x/devices/v3/ssd1306/image1bit"
+)
+
+// EaseFunc maps the fraction t of an animation's duration that has elapsed,
+// from 0 to 1, to the fraction of the change in value to apply.
+type EaseFunc func(t float64) float64
+
+// animationStep is the interval between the steps of Animate.
+const animationStep = 30 * time.Millisecond
+
+// EaseLinear changes the value at a constant rate.
+func EaseLinear(t float64) float64 {
+	return t
+}
+
+// EaseInOut starts and ends slowly, moving fastest halfway through.
+func EaseInOut(t float64) float64 {
+	return (1 - math.Cos(math.Pi*t)) / 2
+}
+
+// Animate moves a value from from to to over dur, shaped by ease, calling
+// render with the current value at each step. render is typically a
+// function that draws a gauge or bar and calls Update. The first call is
+// always made with exactly from and the last with exactly to. A nil ease is
+// linear. If ctx is cancelled, Animate stops and returns the context's error.
+func (d *Display) Animate(ctx context.Context, from, to float64, dur time.Duration, ease EaseFunc, render func(v float64) error) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	if ease == nil {
+		ease = EaseLinear
+	}
+
+	ticker := time.NewTicker(animationStep)
+	defer ticker.Stop()
+
+	start := time.Now()
+	var elapsed time.Duration
+	for {
+		t := 1.0
+		if elapsed < dur {
+			t = float64(elapsed) / float64(dur)
+		}
+
+		v := to
+		if t < 1 {
+			v = from + (to-from)*ease(t)
+		}
+		if err := render(v); err != nil {
+			return err
+		}
+		if t >= 1 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		elapsed = time.Since(start)
+	}
+}
+
+// ShowCursor blinks an inverted block cursor over the character cell at
+// column x of line y until ctx is cancelled. The cursor is XORed onto the
+// rendered frame, so the underlying content is restored when it blinks off.
+// The display is left without a cursor when ShowCursor returns.
+func (d *Display) ShowCursor(ctx context.Context, x, y int, blink time.Duration) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	if blink <= 0 {
+		return fmt.Errorf("blink interval must be positive")
+	}
+
+	cw := d.cellWidth()
+	line := d.lineRect(y)
+	cell := image.Rect(line.Min.X+x*cw, line.Min.Y, line.Min.X+(x+1)*cw, line.Max.Y)
+
+	ticker := time.NewTicker(blink)
+	defer ticker.Stop()
+
+	for {
+		if d.cursor.Empty() {
+			d.cursor = cell
+		} else {
+			d.cursor = image.Rectangle{}
+		}
+
+		if err := d.Update(); err != nil {
+			d.cursor = image.Rectangle{}
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			if d.cursor.Empty() {
+				return nil
+			}
+			d.cursor = image.Rectangle{}
+			return d.Update()
+		case <-ticker.C:
+		}
+	}
+}
+
+// MarqueeHardware shows the current content and scrolls it continuously to
+// the left using the controller's hardware scrolling, which needs no further
+// bus traffic. The controller cannot scroll while its memory is written, so
+// any later draw stops the scroll first. MarqueeHardware returns when that
+// happens or when ctx is cancelled, which also stops the scroll. The driver
+// must implement Scroller.
+func (d *Display) MarqueeHardware(ctx context.Context) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	scroller, ok := d.driver.(Scroller)
+	if !ok {
+		return fmt.Errorf("driver does not support hardware scrolling")
+	}
+
+	if err := d.Update(); err != nil {
+		return err
+	}
+
+	d.mutex.Lock()
+	if err := d.stopScrollLocked(); err != nil {
+		d.mutex.Unlock()
+		return err
+	}
+	if err := scroller.Scroll(ssd1306.Left, ssd1306.FrameRate2, 0, -1); err != nil {
+		d.mutex.Unlock()
+		return fmt.Errorf("failed to start scrolling: %w", err)
+	}
+	stopped := make(chan struct{})
+	d.hwScroll = stopped
+	// Scrolling rotates the controller's memory, so it no longer holds the
+	// last frame drawn.
+	d.haveLastFrame = false
+	d.mutex.Unlock()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.hwScroll != stopped {
+		return nil
+	}
+	return d.stopScrollLocked()
+}
+
+// stopScrollLocked stops a hardware scroll started by MarqueeHardware, if
+// one is running. The caller must hold the mutex.
+func (d *Display) stopScrollLocked() error {
+	if d.hwScroll == nil {
+		return nil
+	}
+
+	close(d.hwScroll)
+	d.hwScroll = nil
+	d.haveLastFrame = false
+	if err := d.driver.(Scroller).StopScroll(); err != nil {
+		return fmt.Errorf("failed to stop scrolling: %w", err)
+	}
+	return nil
+}
+
+// TypeLine reveals text on the given line one character at a time, updating
+// the display after each character and pausing charDelay in between. Only
+// the characters that fit the width of the line in the active font are
+// revealed; if some do not, TypeLine stops there and returns an error
+// wrapping ErrTextTruncated. If ctx is cancelled before the whole line has
+// been revealed, TypeLine stops and returns the context's error.
+func (d *Display) TypeLine(ctx context.Context, line uint, text string, charDelay time.Duration) error {
+	if charDelay <= 0 {
+		return fmt.Errorf("character delay must be positive")
+	}
+
+	defer d.suspendAutoUpdate()()
+
+	if err := d.PrintLine(line, ""); err != nil {
+		return err
+	}
+
+	runes := []rune(text)
+	width := d.textArea().Dx() - d.gutterWidth()
+	n := 0
+	for n < len(runes) && d.textWidth(string(runes[:n+1])) <= width {
+		n++
+	}
+
+	ticker := time.NewTicker(charDelay)
+	defer ticker.Stop()
+
+	for i := range n {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+			}
+		}
+
+		if err := d.PrintLine(line, string(runes[:i+1])); err != nil {
+			return err
+		}
+		if err := d.Update(); err != nil {
+			return err
+		}
+	}
+
+	if n < len(runes) {
+		return fmt.Errorf("%w: revealed %d of %d characters", ErrTextTruncated, n, len(runes))
+	}
+	return nil
+}
+
+// ShowAnimatedGIF plays the frames of g once, showing each for its delay.
+// Playback follows the wall clock rather than the frame count: when drawing
+// falls behind, for instance on a slow bus, frames whose time has already
+// passed are skipped so that the animation keeps to its intended length
+// instead of slowing down. The last frame is always shown. If ctx is
+// cancelled, ShowAnimatedGIF stops and returns the context's error.
+func (d *Display) ShowAnimatedGIF(ctx context.Context, g *gif.GIF) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	if len(g.Image) == 0 {
+		return fmt.Errorf("gif has no frames")
+	}
+
+	canvas := image.NewRGBA(image.Rect(0, 0, g.Config.Width, g.Config.Height))
+	if canvas.Bounds().Empty() {
+		canvas = image.NewRGBA(g.Image[0].Bounds())
+	}
+
+	start := time.Now()
+	var elapsed time.Duration
+	for i, frame := range g.Image {
+		var previous *image.RGBA
+		if i < len(g.Disposal) && g.Disposal[i] == gif.DisposalPrevious {
+			previous = image.NewRGBA(canvas.Bounds())
+			copy(previous.Pix, canvas.Pix)
+		}
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+
+		if i < len(g.Delay) {
+			elapsed += time.Duration(g.Delay[i]) * 10 * time.Millisecond
+		}
+
+		last := i == len(g.Image)-1
+		if last || time.Since(start) < elapsed {
+			if err := d.ShowImage(canvas); err != nil {
+				return err
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Until(start.Add(elapsed))):
+			}
+		}
+
+		switch {
+		case previous != nil:
+			canvas = previous
+		case i < len(g.Disposal) && g.Disposal[i] == gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		}
+	}
+
+	return nil
+}
+
+// ShowImageLayers cycles through layers, showing each for interval, until
+// ctx is cancelled, and then returns the context's error. It suits blinking
+// icons and simple sprite animations built from a few hand-drawn frames. The
+// layers are converted once up front.
+func (d *Display) ShowImageLayers(ctx context.Context, layers []image.Image, interval time.Duration) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	if len(layers) == 0 {
+		return fmt.Errorf("no layers to show")
+	}
+	if interval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+
+	frames := make([]*image1bit.VerticalLSB, len(layers))
+	for i, layer := range layers {
+		frames[i] = d.convertImage(layer)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for i := 0; ; i = (i + 1) % len(frames) {
+		if err := d.showFrame(frames[i]); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Serve renders each set of lines received from frames with Render, until
+// frames is closed, when it returns nil, or ctx is cancelled, when it returns
+// the context's error. Frames that arrive while an earlier one is being
+// drawn are coalesced, so that only the latest is shown and a fast producer
+// cannot make the display fall behind.
+func (d *Display) Serve(ctx context.Context, frames <-chan []string) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	for {
+		var lines []string
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case lines, ok = <-frames:
+			if !ok {
+				return nil
+			}
+		}
+
+	drain:
+		for {
+			select {
+			case next, more := <-frames:
+				if !more {
+					break drain
+				}
+				lines = next
+			default:
+				break drain
+			}
+		}
+
+		if err := d.Render(lines); err != nil {
+			return err
+		}
+	}
+}
+
+// runScreensaver bounces the screensaver image around the display whenever
+// nothing else has been drawn for the configured idle period.
+func (d *Display) runScreensaver(ctx context.Context) {
+	logo := toBitmap(d.screensaver)
+	bounds := d.driver.Bounds()
+	maxPos := bounds.Max.Sub(logo.Bounds().Size())
+	pos := bounds.Min
+	velocity := image.Pt(1, 1)
+
+	ticker := time.NewTicker(d.screensaverInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// Draws are held back while an alert is showing, so lastDraw
+		// going stale must not let the screensaver cover the alert.
+		d.mutex.Lock()
+		if !d.alerting && d.clock().Sub(d.lastDraw) >= d.screensaverIdle {
+			frame := image1bit.NewVerticalLSB(bounds)
+			draw.Draw(frame, logo.Bounds().Add(pos), logo, image.Point{}, draw.Src)
+			d.blank = false
+			d.haveLastFrame = false
+			if err := d.stopScrollLocked(); err != nil {
+				log.Printf("screensaver failed to stop scrolling: %v", err)
+			}
+			if err := d.driver.Draw(bounds, frame, image.Point{}); err != nil {
+				log.Printf("screensaver failed to draw: %v", err)
+			}
+
+			pos.X, velocity.X = bounce(pos.X, velocity.X, bounds.Min.X, maxPos.X)
+			pos.Y, velocity.Y = bounce(pos.Y, velocity.Y, bounds.Min.Y, maxPos.Y)
+		}
+		d.mutex.Unlock()
+	}
+}
+
+// bounce advances pos by velocity, reversing direction at the limits.
+func bounce(pos, velocity, lo, hi int) (int, int) {
+	if hi <= lo {
+		return lo, velocity
+	}
+	next := pos + velocity
+	if next < lo || next > hi {
+		velocity = -velocity
+		next = pos + velocity
+	}
+	return next, velocity
+}
@@ -0,0 +1,474 @@
+package display
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"strings"
+	"testing"
+	"time"
+
+	"periph.io/x/devices/v3/ssd1306"
+	"periph.io/x/devices/v3/ssd1306/image1bit"
+)
+
+func TestDisplay_ShowCursor(t *testing.T) {
+	display, mock := newTestDisplay(t, newTestBuilder())
+
+	assertNoError(t, display.PrintLine(0, "Hello"))
+	assertNoError(t, display.Update())
+	_, src, _ := mock.LastDrawArgs()
+	background := src.(*image1bit.VerticalLSB)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 35*time.Millisecond)
+	defer cancel()
+
+	err := display.ShowCursor(ctx, 1, 0, 10*time.Millisecond)
+	assertNoError(t, err)
+
+	// One draw for the initial Update, then at least two cursor toggles.
+	if mock.CallCount("Draw") < 3 {
+		t.Errorf("Expected at least 2 cursor draws, got %d", mock.CallCount("Draw")-1)
+	}
+
+	_, src, _ = mock.LastDrawArgs()
+	final := src.(*image1bit.VerticalLSB)
+	if !bytes.Equal(final.Pix, background.Pix) {
+		t.Error("Expected final frame to match the background")
+	}
+}
+
+func TestDisplay_ShowCursor_InvertsCell(t *testing.T) {
+	display, mock := newTestDisplay(t, newTestBuilder())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// With a cancelled context the cursor is drawn once and then removed.
+	assertNoError(t, display.ShowCursor(ctx, 2, 1, time.Second))
+
+	if mock.CallCount("Draw") != 2 {
+		t.Fatalf("Expected 2 draws, got %d", mock.CallCount("Draw"))
+	}
+
+	src := mock.DrawnImages()[0]
+	cw := display.cellWidth()
+	cell := image.Rect(2*cw, display.lineHeight, 3*cw, 2*display.lineHeight)
+	if got := countOn(src, cell); got != cell.Dx()*cell.Dy() {
+		t.Errorf("Expected cursor cell to be fully lit, got %d of %d pixels", got, cell.Dx()*cell.Dy())
+	}
+}
+
+// scrollSSD1306 is a tracked fake that records hardware scroll commands.
+type scrollSSD1306 struct {
+	*TrackedFakeSSD1306
+}
+
+func (s *scrollSSD1306) Scroll(o ssd1306.Orientation, rate ssd1306.FrameRate, startLine, endLine int) error {
+	s.record(Call{Method: "Scroll", Args: []interface{}{o, rate, startLine, endLine}})
+	return nil
+}
+
+func (s *scrollSSD1306) StopScroll() error {
+	s.record(Call{Method: "StopScroll"})
+	return nil
+}
+
+// methods returns the names of the calls made, ignoring Bounds.
+func (s *scrollSSD1306) methods() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var methods []string
+	for _, call := range s.Calls {
+		if call.Method != "Bounds" {
+			methods = append(methods, call.Method)
+		}
+	}
+	return methods
+}
+
+func TestDisplay_MarqueeHardware(t *testing.T) {
+	driver := &scrollSSD1306{TrackedFakeSSD1306: NewTrackedFakeSSD1306()}
+	display, err := newTestBuilder().WithDriver(driver).Build()
+	assertNoError(t, err)
+	assertNoError(t, display.Init())
+	assertNoError(t, display.PrintLine(0, "Hello"))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- display.MarqueeHardware(context.Background())
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for !driver.WasCalled("Scroll") {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for scroll to start")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	assertNoError(t, display.PrintLine(1, "World"))
+	assertNoError(t, display.Update())
+
+	select {
+	case err := <-done:
+		assertNoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Expected MarqueeHardware to return once the scroll was stopped")
+	}
+
+	want := "Open,Draw,Scroll,StopScroll,Draw"
+	if got := strings.Join(driver.methods(), ","); got != want {
+		t.Errorf("Expected calls %s, got %s", want, got)
+	}
+}
+
+func TestDisplay_MarqueeHardware_Dedup(t *testing.T) {
+	driver := &scrollSSD1306{TrackedFakeSSD1306: NewTrackedFakeSSD1306()}
+	display, err := newTestBuilder().WithDriver(driver).WithDedup(true).Build()
+	assertNoError(t, err)
+	assertNoError(t, display.Init())
+	assertNoError(t, display.PrintLine(0, "Hello"))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- display.MarqueeHardware(context.Background())
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for !driver.WasCalled("Scroll") {
+		if time.Now().After(deadline) {
+			t.Fatal("Timed out waiting for scroll to start")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// An unchanged frame still stops the scroll, and is drawn again
+	// because scrolling moved the content in the controller's memory.
+	assertNoError(t, display.Update())
+
+	select {
+	case err := <-done:
+		assertNoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Expected an unchanged Update to stop the scroll")
+	}
+
+	want := "Open,Draw,Scroll,StopScroll,Draw"
+	if got := strings.Join(driver.methods(), ","); got != want {
+		t.Errorf("Expected calls %s, got %s", want, got)
+	}
+
+	// Once redrawn, identical frames are skipped again.
+	assertNoError(t, display.Update())
+	if got := driver.CallCount("Draw"); got != 2 {
+		t.Errorf("Expected the repeated frame to be skipped, got %d draws", got)
+	}
+}
+
+func TestDisplay_MarqueeHardware_Cancelled(t *testing.T) {
+	driver := &scrollSSD1306{TrackedFakeSSD1306: NewTrackedFakeSSD1306()}
+	display, err := newTestBuilder().WithDriver(driver).Build()
+	assertNoError(t, err)
+	assertNoError(t, display.Init())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assertNoError(t, display.MarqueeHardware(ctx))
+	if driver.CallCount("StopScroll") != 1 {
+		t.Errorf("Expected scroll to be stopped once, got %d", driver.CallCount("StopScroll"))
+	}
+
+	plain, _ := newTestDisplay(t, newTestBuilder())
+	assertError(t, plain.MarqueeHardware(ctx), "does not support")
+}
+
+// slowSSD1306 is a tracked fake whose Draw takes a fixed time.
+type slowSSD1306 struct {
+	*TrackedFakeSSD1306
+	delay time.Duration
+}
+
+func (s *slowSSD1306) Draw(r image.Rectangle, src image.Image, sp image.Point) error {
+	time.Sleep(s.delay)
+	return s.TrackedFakeSSD1306.Draw(r, src, sp)
+}
+
+// newTestGIF returns an animation of n frames, each lighting one more pixel
+// of the top row and shown for delay hundredths of a second.
+func newTestGIF(n, delay int) *gif.GIF {
+	g := &gif.GIF{Config: image.Config{Width: 128, Height: 64}}
+	palette := color.Palette{color.Black, color.White}
+	for i := range n {
+		frame := image.NewPaletted(image.Rect(i, 0, i+1, 1), palette)
+		frame.SetColorIndex(i, 0, 1)
+		g.Image = append(g.Image, frame)
+		g.Delay = append(g.Delay, delay)
+	}
+	return g
+}
+
+func TestDisplay_ShowAnimatedGIF(t *testing.T) {
+	display, mock := newTestDisplay(t, newTestBuilder())
+
+	assertNoError(t, display.ShowAnimatedGIF(context.Background(), newTestGIF(3, 1)))
+	_, src, _ := mock.LastDrawArgs()
+	if got := countOn(src, image.Rect(0, 0, 128, 1)); got != 3 {
+		t.Errorf("Expected final frame to show all 3 pixels, got %d", got)
+	}
+
+	assertError(t, display.ShowAnimatedGIF(context.Background(), &gif.GIF{}), "no frames")
+}
+
+func TestDisplay_ShowAnimatedGIF_SkipsFramesWhenBehind(t *testing.T) {
+	driver := &slowSSD1306{TrackedFakeSSD1306: NewTrackedFakeSSD1306(), delay: 25 * time.Millisecond}
+	display, err := newTestBuilder().WithDriver(driver).Build()
+	assertNoError(t, err)
+	assertNoError(t, display.Init())
+
+	// Ten frames of 10ms each, drawn by a driver that takes 25ms per frame.
+	start := time.Now()
+	assertNoError(t, display.ShowAnimatedGIF(context.Background(), newTestGIF(10, 1)))
+	elapsed := time.Since(start)
+
+	draws := driver.CallCount("Draw")
+	if draws >= 10 {
+		t.Errorf("Expected frames to be skipped, got %d draws for 10 frames", draws)
+	}
+	if elapsed > 200*time.Millisecond {
+		t.Errorf("Expected playback to take about 100ms, took %v", elapsed)
+	}
+
+	_, src, _ := driver.LastDrawArgs()
+	if got := countOn(src, image.Rect(0, 0, 128, 1)); got != 10 {
+		t.Errorf("Expected the last frame to be shown, got %d lit pixels", got)
+	}
+}
+
+func TestDisplay_ShowImageLayers(t *testing.T) {
+	display, mock := newTestDisplay(t, newTestBuilder())
+
+	on := image.NewGray(image.Rect(0, 0, 8, 8))
+	for i := range on.Pix {
+		on.Pix[i] = 0xff
+	}
+	off := image.NewGray(image.Rect(0, 0, 8, 8))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 25*time.Millisecond)
+	defer cancel()
+	if err := display.ShowImageLayers(ctx, []image.Image{on, off}, 5*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected ShowImageLayers to return the context's error, got %v", err)
+	}
+
+	images := mock.DrawnImages()
+	if len(images) < 2 {
+		t.Fatalf("Expected both layers to be drawn, got %d draws", len(images))
+	}
+	if got := countOn(images[0], image.Rect(0, 0, 8, 8)); got != 64 {
+		t.Errorf("Expected the first layer to be lit, got %d pixels", got)
+	}
+	if got := countOn(images[1], image.Rect(0, 0, 8, 8)); got != 0 {
+		t.Errorf("Expected the second layer to be dark, got %d pixels", got)
+	}
+
+	assertError(t, display.ShowImageLayers(ctx, nil, time.Millisecond), "no layers")
+}
+
+func TestDisplay_Serve(t *testing.T) {
+	display, mock := newTestDisplay(t, newTestBuilder())
+
+	frames := make(chan []string, 5)
+	for i := range 5 {
+		frames <- []string{fmt.Sprintf("frame %d", i)}
+	}
+	close(frames)
+
+	assertNoError(t, display.Serve(context.Background(), frames))
+
+	if display.buffer[0] != "frame 4" {
+		t.Errorf("Expected the last frame to be rendered, got %q", display.buffer[0])
+	}
+	if got := mock.CallCount("Draw"); got != 1 {
+		t.Errorf("Expected the burst to be coalesced into 1 draw, got %d", got)
+	}
+}
+
+func TestDisplay_Serve_Cancelled(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := display.Serve(ctx, make(chan []string)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected Serve to return the context's error, got %v", err)
+	}
+}
+
+func TestDisplay_Animate(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder())
+
+	for name, ease := range map[string]EaseFunc{"linear": EaseLinear, "ease-in-out": EaseInOut} {
+		var values []float64
+		err := display.Animate(context.Background(), 10, 50, 150*time.Millisecond, ease, func(v float64) error {
+			values = append(values, v)
+			return display.FillRect(image.Rect(0, 0, int(v), 8), true)
+		})
+		assertNoError(t, err)
+
+		if len(values) < 3 {
+			t.Fatalf("%s: expected several steps, got %v", name, values)
+		}
+		for i := 1; i < len(values); i++ {
+			if values[i] < values[i-1] {
+				t.Errorf("%s: expected monotonic values, got %v", name, values)
+				break
+			}
+		}
+		if values[0] != 10 || values[len(values)-1] != 50 {
+			t.Errorf("%s: expected values from 10 to 50, got %v", name, values)
+		}
+	}
+}
+
+func TestDisplay_Animate_Cancelled(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := display.Animate(ctx, 0, 1, time.Second, nil, func(float64) error { return nil })
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the context's error, got %v", err)
+	}
+}
+
+func TestDisplay_TypeLine(t *testing.T) {
+	display, mock := newTestDisplay(t, newTestBuilder())
+
+	err := display.TypeLine(context.Background(), 1, "héllo", time.Millisecond)
+	assertNoError(t, err)
+
+	if got := mock.CallCount("Draw"); got != 5 {
+		t.Errorf("Expected one draw per rune (5), got %d", got)
+	}
+	if display.buffer[1] != "héllo" {
+		t.Errorf("Expected buffer[1] to be %q, got %q", "héllo", display.buffer[1])
+	}
+}
+
+func TestDisplay_TypeLine_Cancelled(t *testing.T) {
+	display, mock := newTestDisplay(t, newTestBuilder())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 25*time.Millisecond)
+	defer cancel()
+
+	err := display.TypeLine(ctx, 0, "a fairly long boot message", 10*time.Millisecond)
+	if err != context.DeadlineExceeded {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+
+	if got := mock.CallCount("Draw"); got == 0 || got >= len("a fairly long boot message") {
+		t.Errorf("Expected a partial reveal, got %d draws", got)
+	}
+}
+
+func TestDisplay_TypeLine_TooWide(t *testing.T) {
+	display, mock := newTestDisplay(t, newTestBuilder())
+
+	// 7 pixel wide cells fit 18 characters on a 128 pixel line.
+	text := strings.Repeat("x", 20)
+	err := display.TypeLine(context.Background(), 0, text, time.Millisecond)
+	if !errors.Is(err, ErrTextTruncated) {
+		t.Errorf("Expected ErrTextTruncated, got %v", err)
+	}
+
+	if display.buffer[0] != text[:18] {
+		t.Errorf("Expected the characters that fit to be revealed, got %q", display.buffer[0])
+	}
+	if got := mock.CallCount("Draw"); got != 18 {
+		t.Errorf("Expected one draw per revealed character (18), got %d", got)
+	}
+
+	assertError(t, display.TypeLine(context.Background(), 0, "x", 0), "must be positive")
+}
+
+func TestDisplay_TypeLine_LineOutOfBounds(t *testing.T) {
+	display, mock := newTestDisplay(t, newTestBuilder())
+
+	err := display.TypeLine(context.Background(), DEFAULT_MAX_LINES, "nope", time.Millisecond)
+	assertError(t, err, "display only has")
+
+	if mock.WasCalled("Draw") {
+		t.Error("Expected Draw not to be called")
+	}
+}
+
+func TestDisplay_WithScreensaver(t *testing.T) {
+	logo := NewTestImage(8, 8)
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			logo.Set(x, y, color.White)
+		}
+	}
+
+	builder := newTestBuilder().WithScreensaver(logo, 50*time.Millisecond)
+	builder.screensaverInterval = 5 * time.Millisecond
+	display, mock := newTestDisplay(t, builder)
+	defer display.Close() //nolint:errcheck
+
+	if mock.WasCalled("Draw") {
+		t.Fatal("Expected no draws before the idle period")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if mock.CallCount("Draw") == 0 {
+		t.Fatal("Expected screensaver to draw after the idle period")
+	}
+
+	_, src, _ := mock.LastDrawArgs()
+	if got := countOn(src, src.Bounds()); got != 64 {
+		t.Errorf("Expected screensaver frame to contain the 8x8 logo, got %d lit pixels", got)
+	}
+
+	assertNoError(t, display.PrintLine(0, "Wake up"))
+	assertNoError(t, display.Update())
+	afterUpdate := mock.CallCount("Draw")
+
+	time.Sleep(20 * time.Millisecond)
+	if got := mock.CallCount("Draw"); got != afterUpdate {
+		t.Errorf("Expected screensaver to stop after Update, got %d extra draws", got-afterUpdate)
+	}
+}
+
+func TestDisplay_WithScreensaver_StopsOnClose(t *testing.T) {
+	builder := newTestBuilder().WithScreensaver(NewTestImage(4, 4), time.Millisecond)
+	builder.screensaverInterval = time.Millisecond
+	display, mock := newTestDisplay(t, builder)
+
+	time.Sleep(10 * time.Millisecond)
+	assertNoError(t, display.Close())
+	afterClose := mock.CallCount("Draw")
+
+	time.Sleep(10 * time.Millisecond)
+	if got := mock.CallCount("Draw"); got != afterClose {
+		t.Errorf("Expected no draws after Close, got %d", got-afterClose)
+	}
+}
+
+func TestBounce(t *testing.T) {
+	pos, velocity := 0, 1
+	var positions []int
+	for range 6 {
+		pos, velocity = bounce(pos, velocity, 0, 3)
+		positions = append(positions, pos)
+	}
+
+	want := []int{1, 2, 3, 2, 1, 0}
+	for i := range want {
+		if positions[i] != want[i] {
+			t.Fatalf("Expected positions %v, got %v", want, positions)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package display
+
+import (
+	"fmt"
+	"math"
+)
+
+// SetBrightnessPercent sets the panel contrast from a percentage, where 0
+// maps to contrast 0 and 100 to contrast 255. The driver must implement
+// ContrastSetter.
+func (d *Display) SetBrightnessPercent(p int) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	if p < 0 || p > 100 {
+		return fmt.Errorf("invalid brightness %d%%: must be between 0 and 100", p)
+	}
+
+	setter, ok := d.driver.(ContrastSetter)
+	if !ok {
+		return fmt.Errorf("driver does not support setting contrast")
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if err := setter.SetContrast(percentToContrast(p)); err != nil {
+		return fmt.Errorf("failed to set contrast: %w", err)
+	}
+	d.brightness = p
+	return nil
+}
+
+// BrightnessPercent returns the brightness last set with
+// SetBrightnessPercent, or 100 if it has not been changed.
+func (d *Display) BrightnessPercent() int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.brightness
+}
+
+func percentToContrast(p int) byte {
+	return byte(math.Round(float64(p) * 255 / 100))
+}
+
+func contrastToPercent(level byte) int {
+	return int(math.Round(float64(level) * 100 / 255))
+}
@@ -0,0 +1,88 @@
+package display
+
+import (
+	"testing"
+)
+
+// contrastSSD1306 is a tracked fake that records the contrast it was set to.
+type contrastSSD1306 struct {
+	*TrackedFakeSSD1306
+	level byte
+}
+
+func (c *contrastSSD1306) SetContrast(level byte) error {
+	c.level = level
+	return nil
+}
+
+func TestPercentToContrast(t *testing.T) {
+	tests := []struct {
+		percent  int
+		contrast byte
+	}{
+		{0, 0},
+		{50, 128},
+		{100, 255},
+	}
+
+	for _, tt := range tests {
+		if got := percentToContrast(tt.percent); got != tt.contrast {
+			t.Errorf("percentToContrast(%d): expected %d, got %d", tt.percent, tt.contrast, got)
+		}
+		if got := contrastToPercent(tt.contrast); got != tt.percent {
+			t.Errorf("contrastToPercent(%d): expected %d, got %d", tt.contrast, tt.percent, got)
+		}
+	}
+
+	for p := range 101 {
+		if got := contrastToPercent(percentToContrast(p)); got != p {
+			t.Errorf("Expected %d%% to survive a round trip, got %d%%", p, got)
+		}
+	}
+}
+
+func TestDisplay_SetBrightnessPercent(t *testing.T) {
+	driver := &contrastSSD1306{TrackedFakeSSD1306: NewTrackedFakeSSD1306()}
+	display, err := newTestBuilder().WithDriver(driver).Build()
+	assertNoError(t, err)
+	assertNoError(t, display.Init())
+
+	if got := display.BrightnessPercent(); got != 100 {
+		t.Errorf("Expected initial brightness 100, got %d", got)
+	}
+
+	assertNoError(t, display.SetBrightnessPercent(50))
+	if driver.level != 128 {
+		t.Errorf("Expected contrast 128, got %d", driver.level)
+	}
+	if got := display.BrightnessPercent(); got != 50 {
+		t.Errorf("Expected brightness 50, got %d", got)
+	}
+
+	assertError(t, display.SetBrightnessPercent(101), "invalid brightness")
+
+	plain := newMinimalDisplay(t)
+	assertError(t, plain.SetBrightnessPercent(50), "does not support")
+}
+
+func TestDisplay_BrightnessPercent_Concurrent(t *testing.T) {
+	driver := &contrastSSD1306{TrackedFakeSSD1306: NewTrackedFakeSSD1306()}
+	display, err := newTestBuilder().WithDriver(driver).Build()
+	assertNoError(t, err)
+	assertNoError(t, display.Init())
+
+	// Run with -race to catch reads that are not guarded by the mutex.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for p := range 100 {
+			_ = display.SetBrightnessPercent(p)
+		}
+	}()
+	for range 100 {
+		if got := display.BrightnessPercent(); got < 0 || got > 100 {
+			t.Errorf("Expected brightness in range, got %d", got)
+		}
+	}
+	<-done
+}
@@ -0,0 +1,71 @@
+package display
+
+import (
+	"fmt"
+	"sync"
+
+	"periph.io/x/conn/v3/i2c"
+)
+
+type (
+	// sharedBus is an open i2c bus shared by every RealSSD1306 on the
+	// same bus name.
+	sharedBus struct {
+		i2c.BusCloser
+		name string
+		refs int
+	}
+
+	// busRef is one user's handle on a sharedBus. Closing it releases
+	// that user's reference; the bus itself is closed with the last one.
+	busRef struct {
+		*sharedBus
+		once sync.Once
+	}
+)
+
+var (
+	busesMutex sync.Mutex
+	buses      = make(map[string]*sharedBus)
+)
+
+// acquireBus returns a handle on the named bus, opening it, and initializing
+// periph, only if no other handle on it is open.
+func acquireBus(name string) (i2c.BusCloser, error) {
+	busesMutex.Lock()
+	defer busesMutex.Unlock()
+
+	shared, ok := buses[name]
+	if !ok {
+		if _, err := hostInit(); err != nil {
+			return nil, fmt.Errorf("failed to initialize display: %w", err)
+		}
+
+		b, err := openBus(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open i2c bus %s: %w", name, err)
+		}
+
+		shared = &sharedBus{BusCloser: b, name: name}
+		buses[name] = shared
+	}
+
+	shared.refs++
+	return &busRef{sharedBus: shared}, nil
+}
+
+// Close releases this handle. It is safe to call more than once.
+func (r *busRef) Close() error {
+	var err error
+	r.once.Do(func() {
+		busesMutex.Lock()
+		defer busesMutex.Unlock()
+
+		r.refs--
+		if r.refs == 0 {
+			delete(buses, r.name)
+			err = r.BusCloser.Close()
+		}
+	})
+	return err
+}
@@ -0,0 +1,59 @@
+package display
+
+import (
+	"testing"
+
+	"periph.io/x/conn/v3/i2c"
+)
+
+func TestAcquireBus_SharesOpenBus(t *testing.T) {
+	bus := withFakeBus(t)
+	opens := 0
+	openBus = func(string) (i2c.BusCloser, error) {
+		opens++
+		return bus, nil
+	}
+
+	first, err := acquireBus("fake")
+	assertNoError(t, err)
+	second, err := acquireBus("fake")
+	assertNoError(t, err)
+	if opens != 1 {
+		t.Errorf("Expected the second acquire to reuse the open bus, got %d opens", opens)
+	}
+
+	assertNoError(t, first.Close())
+	assertNoError(t, first.Close())
+	if bus.closed {
+		t.Fatal("Expected the bus to stay open while it is still in use")
+	}
+
+	assertNoError(t, second.Close())
+	if !bus.closed {
+		t.Error("Expected the bus to be closed with its last user")
+	}
+
+	_, err = acquireBus("fake")
+	assertNoError(t, err)
+	if opens != 2 {
+		t.Errorf("Expected the bus to be reopened after it was closed, got %d opens", opens)
+	}
+}
+
+func TestRealSSD1306_SharesBus(t *testing.T) {
+	bus := withFakeBus(t)
+
+	first := NewRealSSD1306("fake")
+	second := NewRealSSD1306("fake")
+	assertNoError(t, first.Open())
+	assertNoError(t, second.Open())
+
+	assertNoError(t, first.Close())
+	if bus.closed {
+		t.Fatal("Expected the bus to stay open while another display uses it")
+	}
+	assertNoError(t, second.Close())
+	if !bus.closed {
+		t.Error("Expected the bus to be closed with the last display")
+	}
+}
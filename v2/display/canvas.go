@@ -0,0 +1,80 @@
+package display
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+
+	"golang.org/x/image/font"
+)
+
+type (
+	// Canvas is an off-screen display. It supports the same text and
+	// drawing methods as Display, but renders into memory so that the
+	// result can be composited onto a Display with DrawCanvas.
+	Canvas struct {
+		*Display
+	}
+
+	// memSSD1306 is a driver with no output, used by Canvas.
+	memSSD1306 struct {
+		bounds image.Rectangle
+	}
+)
+
+func (m *memSSD1306) Open() error {
+	return nil
+}
+
+func (m *memSSD1306) Close() error {
+	return nil
+}
+
+func (m *memSSD1306) Bounds() image.Rectangle {
+	return m.bounds
+}
+
+func (m *memSSD1306) Draw(r image.Rectangle, src image.Image, sp image.Point) error {
+	return nil
+}
+
+// NewCanvas returns a canvas of the given size that renders text with face.
+// If face is nil, the default font is used. The canvas has as many text lines
+// as fit in its height.
+func NewCanvas(bounds image.Rectangle, face font.Face) (*Canvas, error) {
+	builder := NewDisplay().WithDriver(&memSSD1306{bounds: bounds})
+	if face != nil {
+		builder = builder.WithFont(face)
+	}
+
+	d, err := builder.Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create canvas: %w", err)
+	}
+	d.lines = uint(d.linesInHeight(bounds.Dy()))
+
+	if err := d.Init(); err != nil {
+		return nil, fmt.Errorf("failed to create canvas: %w", err)
+	}
+
+	return &Canvas{Display: d}, nil
+}
+
+// Image returns the current contents of the canvas: its framebuffer with the
+// text lines drawn over it.
+func (c *Canvas) Image() image.Image {
+	return c.render()
+}
+
+// DrawCanvas copies the contents of a canvas into the framebuffer with the top
+// left corner of the canvas at the given point.
+func (d *Display) DrawCanvas(c *Canvas, at image.Point) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	src := c.Image()
+	r := src.Bounds().Sub(src.Bounds().Min).Add(at)
+	draw.Draw(clippedImage{Image: d.fb, clip: d.clipRect()}, r, src, src.Bounds().Min, draw.Src)
+	return d.changed()
+}
@@ -0,0 +1,42 @@
+package display
+
+import (
+	"image"
+	"testing"
+
+	"golang.org/x/image/font/basicfont"
+
+	"periph.io/x/devices/v3/ssd1306/image1bit"
+)
+
+func TestDisplay_DrawCanvas(t *testing.T) {
+	canvas, err := NewCanvas(image.Rect(0, 0, 40, 30), basicfont.Face7x13)
+	assertNoError(t, err)
+	if len(canvas.buffer) != 2 {
+		t.Fatalf("Expected a 30 pixel canvas to fit 2 lines, got %d", len(canvas.buffer))
+	}
+
+	assertNoError(t, canvas.PrintLine(0, "Hi"))
+	assertNoError(t, canvas.DrawLine(0, 29, 39, 29, true))
+	img := canvas.Image()
+
+	display, mock := newTestDisplay(t, newTestBuilder())
+	at := image.Pt(50, 20)
+	assertNoError(t, display.DrawCanvas(canvas, at))
+	assertNoError(t, display.Update())
+	_, src, _ := mock.LastDrawArgs()
+
+	for y := range 30 {
+		for x := range 40 {
+			if src.At(x+at.X, y+at.Y) != img.At(x, y) {
+				t.Fatalf("Expected canvas pixel (%d,%d) at (%d,%d)", x, y, x+at.X, y+at.Y)
+			}
+		}
+	}
+	if got, want := countOn(src, src.Bounds()), countOn(img, img.Bounds()); got != want || want <= 40 {
+		t.Errorf("Expected only the %d canvas pixels to be lit, got %d", want, got)
+	}
+	if src.At(at.X, at.Y+29) != image1bit.On {
+		t.Error("Expected the canvas line to land at the bottom of the canvas")
+	}
+}
@@ -0,0 +1,61 @@
+package display
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"slices"
+
+	"periph.io/x/devices/v3/ssd1306/image1bit"
+)
+
+// DrawLineChart plots series as a connected line scaled to fill area,
+// drawing it into the framebuffer. The first value is drawn at the left edge
+// and the last at the right edge, with the smallest value at the bottom and
+// the largest at the top. With axes, x and y axis lines are drawn along the
+// bottom and left of area and the plot is inset to leave room for them. An
+// empty series draws only the axes, and a series with a single value, or
+// whose values are all the same, is drawn as a flat line.
+func (d *Display) DrawLineChart(area image.Rectangle, series []float64, axes bool) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	area = area.Canon().Intersect(d.driver.Bounds())
+	if area.Empty() {
+		return fmt.Errorf("chart area does not overlap the display")
+	}
+
+	plot := area
+	if axes {
+		d.drawLine(area.Min.X, area.Min.Y, area.Min.X, area.Max.Y-1, image1bit.On)
+		d.drawLine(area.Min.X, area.Max.Y-1, area.Max.X-1, area.Max.Y-1, image1bit.On)
+		plot.Min.X++
+		plot.Max.Y--
+	}
+
+	if len(series) > 0 && !plot.Empty() {
+		if len(series) == 1 {
+			series = []float64{series[0], series[0]}
+		}
+
+		lo, hi := slices.Min(series), slices.Max(series)
+		point := func(i int) (int, int) {
+			x := plot.Min.X + i*(plot.Dx()-1)/(len(series)-1)
+			if hi == lo {
+				return x, plot.Min.Y + (plot.Dy()-1)/2
+			}
+			scaled := (series[i] - lo) / (hi - lo) * float64(plot.Dy()-1)
+			return x, plot.Max.Y - 1 - int(math.Round(scaled))
+		}
+
+		x0, y0 := point(0)
+		for i := 1; i < len(series); i++ {
+			x1, y1 := point(i)
+			d.drawLine(x0, y0, x1, y1, image1bit.On)
+			x0, y0 = x1, y1
+		}
+	}
+
+	return d.changed()
+}
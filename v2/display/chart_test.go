@@ -0,0 +1,57 @@
+package display
+
+import (
+	"image"
+	"testing"
+
+	"periph.io/x/devices/v3/ssd1306/image1bit"
+)
+
+func TestDisplay_DrawLineChart(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder())
+
+	area := image.Rect(10, 10, 74, 42)
+	assertNoError(t, display.DrawLineChart(area, []float64{0, 5, 10, 5, 0}, true))
+
+	for x := area.Min.X; x < area.Max.X; x++ {
+		if display.fb.At(x, area.Max.Y-1) != image1bit.On {
+			t.Fatalf("Expected x axis pixel at (%d, %d) to be on", x, area.Max.Y-1)
+		}
+	}
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		if display.fb.At(area.Min.X, y) != image1bit.On {
+			t.Fatalf("Expected y axis pixel at (%d, %d) to be on", area.Min.X, y)
+		}
+	}
+
+	// The plot is inset by the axes and runs from the bottom left, up to
+	// the top in the middle, and back down to the bottom right.
+	plot := image.Rect(area.Min.X+1, area.Min.Y, area.Max.X, area.Max.Y-1)
+	extent := litExtent(clippedImage{Image: display.fb, clip: plot})
+	if extent != plot {
+		t.Errorf("Expected plotted line to span %v, got %v", plot, extent)
+	}
+	if display.fb.At(plot.Min.X, plot.Max.Y-1) != image1bit.On || display.fb.At(plot.Max.X-1, plot.Max.Y-1) != image1bit.On {
+		t.Error("Expected line to start and end at the bottom of the plot")
+	}
+	if display.fb.At(plot.Min.X+(plot.Dx()-1)/2, plot.Min.Y) != image1bit.On {
+		t.Error("Expected peak at the top middle of the plot")
+	}
+}
+
+func TestDisplay_DrawLineChart_DegenerateSeries(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder())
+	area := image.Rect(0, 0, 32, 16)
+
+	assertNoError(t, display.DrawLineChart(area, nil, false))
+	if got := countOn(display.fb, area); got != 0 {
+		t.Errorf("Expected empty series without axes to draw nothing, got %d pixels", got)
+	}
+
+	assertNoError(t, display.DrawLineChart(area, []float64{7}, false))
+	if got := countOn(display.fb, area); got != area.Dx() {
+		t.Errorf("Expected single value to draw a flat line %d pixels wide, got %d", area.Dx(), got)
+	}
+
+	assertError(t, display.DrawLineChart(image.Rect(200, 200, 210, 210), []float64{1, 2}, true), "does not overlap")
+}
@@ -0,0 +1,14 @@
+//go:build !nofont
+
+package display
+
+import (
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+)
+
+// defaultFont returns the face used when no font has been configured.
+// Building with the nofont tag drops it, along with the basicfont package.
+func defaultFont() font.Face {
+	return basicfont.Face7x13
+}
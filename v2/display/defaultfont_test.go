@@ -0,0 +1,92 @@
+//go:build !nofont
+
+package display
+
+import (
+	"image"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/image/font/basicfont"
+)
+
+func TestDisplay_Build_WithDefaultFont(t *testing.T) {
+	display := NewDisplay()
+	built, err := display.Build()
+	assertNoError(t, err)
+
+	// Should have default font set
+	if built.font == nil {
+		t.Error("Expected default font to be set")
+	}
+
+	expectedHeight := basicfont.Face7x13.Metrics().Height.Ceil()
+	if built.lineHeight != expectedHeight {
+		t.Errorf("Expected lineHeight to be %d, got %d", expectedHeight, built.lineHeight)
+	}
+}
+
+func TestDisplay_Build_FontFromEnv(t *testing.T) {
+	basicHeight := basicfont.Face7x13.Metrics().Height.Ceil()
+
+	tests := []struct {
+		name        string
+		font        func(t *testing.T) string
+		size        string
+		wantDefault bool
+	}{
+		{
+			name: "valid font and size",
+			font: writeTestFont,
+			size: "24",
+		},
+		{
+			name: "valid font without size",
+			font: writeTestFont,
+		},
+		{
+			name:        "missing font file",
+			font:        func(t *testing.T) string { return filepath.Join(t.TempDir(), "missing.ttf") },
+			size:        "24",
+			wantDefault: true,
+		},
+		{
+			name:        "invalid size",
+			font:        writeTestFont,
+			size:        "big",
+			wantDefault: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DISPLAY1306_FONT", tt.font(t))
+			t.Setenv("DISPLAY1306_FONT_SIZE", tt.size)
+
+			display, err := NewDisplay().Build()
+			assertNoError(t, err)
+
+			if tt.wantDefault {
+				if display.font != basicfont.Face7x13 {
+					t.Error("Expected fallback to basicfont")
+				}
+				return
+			}
+
+			if display.font == basicfont.Face7x13 {
+				t.Fatal("Expected font from environment to be used")
+			}
+			if tt.size != "" && display.lineHeight == basicHeight {
+				t.Errorf("Expected lineHeight to differ from basicfont's %d", basicHeight)
+			}
+		})
+	}
+}
+
+func TestNewCanvas_DefaultFont(t *testing.T) {
+	canvas, err := NewCanvas(image.Rect(0, 0, 40, 30), nil)
+	assertNoError(t, err)
+	if canvas.font != basicfont.Face7x13 {
+		t.Error("Expected a canvas without a font to use basicfont")
+	}
+}
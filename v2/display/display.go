@@ -1,40 +1,188 @@
 package display
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"image"
 	"image/color"
-	_ "image/gif"
-	_ "image/jpeg"
-	_ "image/png"
+	"io"
+	"log"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
-	_ "golang.org/x/image/bmp"
+	"github.com/golang/freetype/truetype"
 	"golang.org/x/image/font"
-	"golang.org/x/image/font/basicfont"
 	"golang.org/x/image/math/fixed"
+	"periph.io/x/conn/v3/physic"
+	"periph.io/x/devices/v3/ssd1306"
 	"periph.io/x/devices/v3/ssd1306/image1bit"
 )
 
+var (
+	ErrDrawTimeout = errors.New("draw timed out")
+)
+
 const (
-	DEFAULT_MAX_LINES uint = 5
+	DEFAULT_MAX_LINES            uint = 5
+	DEFAULT_SCREENSAVER_INTERVAL      = 100 * time.Millisecond
+	DEFAULT_ALERT_INTERVAL            = 250 * time.Millisecond
 )
 
 type (
 	Display struct {
-		busName     string
-		driver      SSD1306
-		lines       uint
-		buffer      []string
-		font        font.Face
-		lineHeight  int
-		initialized bool
+		busName      string
+		driver       SSD1306
+		deviceOpts   *ssd1306.Opts
+		columnOffset int
+		busFrequency physic.Frequency
+		externalVCC  bool
+		lines        uint
+		buffer       []string
+		fb           *image1bit.VerticalLSB
+		font         font.Face
+		ttf          *truetype.Font
+		lineFaces    map[int]font.Face
+		// busLockPath and busLockFailFast configure WithBusLockFile;
+		// busLock is the open lock file while the lock is held.
+		busLockPath     string
+		busLockFailFast bool
+		busLock         *os.File
+		// lineOffsets shifts individual lines vertically, in pixels.
+		lineOffsets map[int]int
+		// lineBackground and lineForeground override the colors of
+		// individual lines, which default to on text over an off
+		// background, or the reverse with WithInvertedBackground.
+		lineBackground map[int]bool
+		lineForeground map[int]bool
+		lineHeight     int
+		initialized    bool
+		cursor         image.Rectangle
+		// clip restricts framebuffer writes while clipping is set.
+		clip     image.Rectangle
+		clipping bool
+		viewport image.Rectangle
+		spacing  int
+		err      error
+
+		// scrollNext is the line PrintLineScroll writes to next when
+		// filling the display from the top.
+		scrollNext int
+		// headerLines is the number of lines at the top of the display
+		// that PrintLineScroll and Append leave alone.
+		headerLines   uint
+		reverseScroll bool
+		lineNumbers   bool
+		textOutline   bool
+		screenBorder  bool
+		trimTrailing  bool
+		trimLeading   bool
+		sanitizeUTF8  bool
+		// invertedBackground renders lines dark on a lit background.
+		invertedBackground bool
+		// placeholder replaces runes the font has no glyph for when
+		// rendering text lines. Zero leaves them to the font.
+		placeholder rune
+
+		// history holds every line added with Append.
+		history          []string
+		autoScrollScreen bool
+
+		// mutex serializes access to the driver between the caller and
+		// background tasks such as the screensaver.
+		mutex    sync.Mutex
+		lastDraw time.Time
+		// blank is true while the panel is known to be showing an empty
+		// frame.
+		blank bool
+		// hwScroll is closed and cleared when a hardware scroll started by
+		// MarqueeHardware is stopped. It is nil while not scrolling.
+		hwScroll chan struct{}
+		// lastFrame is the hash of the frame on the panel, used by
+		// WithDedup. It is only meaningful if haveLastFrame is set.
+		lastFrame        uint64
+		haveLastFrame    bool
+		backgroundCtx    context.Context
+		cancelBackground context.CancelFunc
+		background       sync.WaitGroup
+
+		screensaver         image.Image
+		screensaverIdle     time.Duration
+		screensaverInterval time.Duration
+
+		// alertCond and alertFrame are set by SetAlert and guarded by
+		// alertMutex, as is alertRunning, which is set once the first
+		// alert has started the background check. alerting is true, under
+		// mutex, while the alert is covering the panel; shown is the frame
+		// it will restore.
+		alertMutex    sync.Mutex
+		alertCond     func() bool
+		alertFrame    *image1bit.VerticalLSB
+		alertRunning  bool
+		alertInterval time.Duration
+		alertBlink    bool
+		alerting      bool
+		shown         image.Image
+
+		clock        func() time.Time
+		drawTimeout  time.Duration
+		gamma        float64
+		autoContrast bool
+		// brightness is the last percentage set with
+		// SetBrightnessPercent. The controller starts at full contrast.
+		brightness int
+
+		// imageCache holds converted frames from ShowImageFromFile, keyed by
+		// path. It is nil unless enabled with WithImageCache.
+		imageCache map[string]cachedImage
+		// maxImageBytes and maxImageSize limit the images that
+		// ShowImageFromFile and ShowImageFromReader will decode. Zero means
+		// no limit.
+		maxImageBytes int64
+		maxImageSize  image.Point
+
+		// dirty is set by any change to the text buffer or framebuffer and
+		// cleared by a successful Update.
+		dirty      bool
+		autoUpdate bool
+		alwaysDraw bool
+		dedup      bool
+		// suspended counts nested operations during which auto-update is
+		// held back so that they produce a single draw.
+		suspended int
+	}
+
+	cachedImage struct {
+		modTime time.Time
+		frame   *image1bit.VerticalLSB
 	}
 )
 
+// borderWidth is the width of the frame drawn by WithScreenBorder.
+const borderWidth = 1
+
+// decodeImage is a variable so that tests can count decodes.
+var decodeImage = image.Decode
+
 func NewDisplay() *Display {
 	return &Display{
-		lines: DEFAULT_MAX_LINES,
+		lines:               DEFAULT_MAX_LINES,
+		lineFaces:           make(map[int]font.Face),
+		lineOffsets:         make(map[int]int),
+		lineBackground:      make(map[int]bool),
+		lineForeground:      make(map[int]bool),
+		screensaverInterval: DEFAULT_SCREENSAVER_INTERVAL,
+		alertInterval:       DEFAULT_ALERT_INTERVAL,
+		clock:               time.Now,
+		gamma:               1,
+		brightness:          100,
 	}
 }
 
@@ -48,22 +196,319 @@ func (d *Display) WithBusName(busName string) *Display {
 	return d
 }
 
+// WithDeviceOpts sets the options used to initialize the SSD1306 when Init
+// creates the real driver. It has no effect when a driver is provided with
+// WithDriver.
+func (d *Display) WithDeviceOpts(opts ssd1306.Opts) *Display {
+	d.deviceOpts = &opts
+	return d
+}
+
+// WithColumnOffset shifts the image n columns to the right on the panel,
+// for modules whose controller RAM is wider than the glass so that content
+// appears shifted. Like WithDeviceOpts, it only applies to the real driver.
+func (d *Display) WithColumnOffset(n int) *Display {
+	if n < 0 {
+		d.err = fmt.Errorf("invalid column offset %d", n)
+	}
+	d.columnOffset = n
+	return d
+}
+
+// WithBusFrequency sets the i2c bus clock, for instance to 400kHz on short
+// wiring for faster frame rates. Like WithDeviceOpts, it only applies to the
+// real driver. Displays sharing a bus share its speed, so the last one
+// opened wins.
+func (d *Display) WithBusFrequency(hz physic.Frequency) *Display {
+	if hz < 0 {
+		d.err = fmt.Errorf("invalid bus frequency %s", hz)
+	}
+	d.busFrequency = hz
+	return d
+}
+
+// WithExternalVCC configures the controller for modules wired for an
+// external VCC supply, which stay dark with the charge pump enabled. Like
+// WithDeviceOpts, it only applies to the real driver.
+func (d *Display) WithExternalVCC(external bool) *Display {
+	d.externalVCC = external
+	return d
+}
+
+// WithBusLockFile makes Init take an exclusive advisory lock on the file at
+// path, creating it if needed, before opening the bus, and Close release it.
+// Processes that share a bus and use the same lock file then take turns
+// instead of interleaving their writes. Init waits for the lock unless
+// WithBusLockFailFast is set.
+func (d *Display) WithBusLockFile(path string) *Display {
+	d.busLockPath = path
+	return d
+}
+
+// WithBusLockFailFast makes Init return an error instead of waiting when
+// the lock set with WithBusLockFile is held by someone else.
+func (d *Display) WithBusLockFailFast(failFast bool) *Display {
+	d.busLockFailFast = failFast
+	return d
+}
+
 func (d *Display) WithDriver(driver SSD1306) *Display {
 	d.driver = driver
 	return d
 }
 
 func (d *Display) WithFont(f font.Face) *Display {
-	d.font = f
-	d.lineHeight = f.Metrics().Height.Ceil()
+	if err := d.SetFont(f); err != nil {
+		d.err = err
+	}
+	return d
+}
+
+// WithViewport restricts text rendering to the given rectangle of the
+// display. Pixels outside of the viewport are left untouched by Update.
+func (d *Display) WithViewport(r image.Rectangle) *Display {
+	d.viewport = r
+	return d
+}
+
+// WithScreensaver bounces img around the display once nothing has been drawn
+// for the idle duration, until the next Update or ShowImage.
+func (d *Display) WithScreensaver(img image.Image, idle time.Duration) *Display {
+	d.screensaver = img
+	d.screensaverIdle = idle
+	return d
+}
+
+// WithReverseScroll makes PrintLineScroll and Write always place the newest
+// line at the bottom of the display, pushing earlier lines up.
+func (d *Display) WithReverseScroll(enabled bool) *Display {
+	d.reverseScroll = enabled
+	return d
+}
+
+// WithAutoScrollScreen lets Append add any number of lines, scrolling older
+// lines off the top of the display.
+func (d *Display) WithAutoScrollScreen(enabled bool) *Display {
+	d.autoScrollScreen = enabled
+	return d
+}
+
+// WithLineNumbers renders a gutter of right-aligned line numbers to the left
+// of the text lines.
+func (d *Display) WithLineNumbers(enabled bool) *Display {
+	d.lineNumbers = enabled
+	return d
+}
+
+// WithTextOutline draws a one pixel outline of unlit pixels around text, so
+// that it stays readable when drawn over an image.
+func (d *Display) WithTextOutline(enabled bool) *Display {
+	d.textOutline = enabled
+	return d
+}
+
+// WithScreenBorder draws a one pixel frame around the edge of the display
+// and insets text so that it does not touch the frame.
+func (d *Display) WithScreenBorder(enabled bool) *Display {
+	d.screenBorder = enabled
+	return d
+}
+
+// WithInvertedBackground renders the display as dark text on a lit
+// background. Unlike inverting the panel in hardware, only the rendered
+// frame changes: the framebuffer keeps its usual meaning, and whatever is
+// drawn into it shows dark.
+func (d *Display) WithInvertedBackground(inverted bool) *Display {
+	d.invertedBackground = inverted
+	return d
+}
+
+// WithTrimLines removes trailing whitespace from text lines before they are
+// stored, so that lines read from files or stdin render the same whatever
+// their line endings.
+func (d *Display) WithTrimLines(enabled bool) *Display {
+	d.trimTrailing = enabled
+	return d
+}
+
+// WithMissingGlyphPlaceholder makes Update draw r in place of any rune in a
+// text line that the font has no glyph for, so that encoding problems show
+// up as visible marks rather than gaps. r should be a rune the font can
+// draw.
+func (d *Display) WithMissingGlyphPlaceholder(r rune) *Display {
+	d.placeholder = r
+	return d
+}
+
+// WithSanitizeUTF8 replaces invalid UTF-8 in text lines with U+FFFD before
+// they are stored, so that arbitrary input does not upset rune-based layout.
+func (d *Display) WithSanitizeUTF8(enabled bool) *Display {
+	d.sanitizeUTF8 = enabled
+	return d
+}
+
+// WithTrimLeadingSpace removes leading whitespace from text lines before
+// they are stored.
+func (d *Display) WithTrimLeadingSpace(enabled bool) *Display {
+	d.trimLeading = enabled
+	return d
+}
+
+// WithHeaderLines keeps the top n lines fixed while PrintLineScroll, Write
+// and Append scroll the lines below them. Header lines are still set with
+// PrintLine.
+func (d *Display) WithHeaderLines(n uint) *Display {
+	d.headerLines = n
+	return d
+}
+
+// WithLetterSpacing adds px pixels of space after each glyph when rendering
+// text lines. Negative values tighten the text, but glyphs always advance by
+// at least one pixel.
+func (d *Display) WithLetterSpacing(px int) *Display {
+	d.spacing = px
+	return d
+}
+
+// WithClock sets the function used to read the current time for all
+// time-dependent rendering. The default is time.Now.
+func (d *Display) WithClock(clock func() time.Time) *Display {
+	d.clock = clock
+	return d
+}
+
+// WithTrueTypeFont uses a TrueType font at the given size. Unlike WithFont,
+// this allows features such as PrintLineFit to render the font at other
+// sizes.
+func (d *Display) WithTrueTypeFont(tf *truetype.Font, size float64) *Display {
+	if err := d.SetFont(newTrueTypeFace(tf, size)); err != nil {
+		d.err = err
+		return d
+	}
+	d.ttf = tf
+	return d
+}
+
+// WithFontName finds a TrueType font file by name in the font search path
+// and uses it at the given size. See findFont for how names are matched.
+func (d *Display) WithFontName(name string, size float64) *Display {
+	path, err := findFont(name)
+	if err != nil {
+		d.err = err
+		return d
+	}
+
+	tf, err := loadTrueTypeFont(path)
+	if err != nil {
+		d.err = err
+		return d
+	}
+
+	return d.WithTrueTypeFont(tf, size)
+}
+
+// WithDrawTimeout bounds how long Update and ShowImage wait for the driver to
+// draw a frame. This is best-effort: a draw that times out is abandoned but
+// keeps running in the background, and may still complete later.
+func (d *Display) WithDrawTimeout(timeout time.Duration) *Display {
+	d.drawTimeout = timeout
+	return d
+}
+
+// WithGamma applies gamma correction to image luminance before it is
+// converted to 1-bit. Values below 1 brighten midtones and values above 1
+// darken them. The default of 1 leaves images unchanged.
+func (d *Display) WithGamma(g float64) *Display {
+	if g <= 0 {
+		d.err = fmt.Errorf("invalid gamma %f", g)
+		return d
+	}
+	d.gamma = g
+	return d
+}
+
+// WithAutoContrast stretches the luminance range of images to the full 0-255
+// range before they are converted to 1-bit.
+func (d *Display) WithAutoContrast(enabled bool) *Display {
+	d.autoContrast = enabled
+	return d
+}
+
+// WithMaxImageBytes makes ShowImageFromFile and ShowImageFromReader reject
+// image data larger than n bytes. Zero disables the limit.
+func (d *Display) WithMaxImageBytes(n int64) *Display {
+	if n < 0 {
+		d.err = fmt.Errorf("invalid maximum image size %d bytes", n)
+	}
+	d.maxImageBytes = n
+	return d
+}
+
+// WithMaxImageDimensions makes ShowImageFromFile and ShowImageFromReader
+// reject images wider than w or taller than h pixels, as declared in the
+// image header, before decoding them. Zero disables the limit.
+func (d *Display) WithMaxImageDimensions(w, h int) *Display {
+	if w < 0 || h < 0 {
+		d.err = fmt.Errorf("invalid maximum image dimensions %dx%d", w, h)
+	}
+	d.maxImageSize = image.Pt(w, h)
+	return d
+}
+
+// WithImageCache makes ShowImageFromFile keep the converted frame for each
+// file so that showing it again does not decode it again. A cached frame is
+// discarded when the file's modification time changes.
+func (d *Display) WithImageCache(enabled bool) *Display {
+	if enabled {
+		d.imageCache = make(map[string]cachedImage)
+	} else {
+		d.imageCache = nil
+	}
+	return d
+}
+
+// WithAutoUpdate makes every method that changes the text buffer or
+// framebuffer update the display immediately, without a separate call to
+// Update. It is off by default because each change then costs a full frame.
+func (d *Display) WithAutoUpdate(enabled bool) *Display {
+	d.autoUpdate = enabled
+	return d
+}
+
+// WithAlwaysDraw makes Update draw a frame even when it is empty and the
+// panel is already blank.
+func (d *Display) WithAlwaysDraw(enabled bool) *Display {
+	d.alwaysDraw = enabled
+	return d
+}
+
+// WithDedup skips sending a frame to the driver when it is identical to the
+// last frame sent, saving bus traffic for static screens that are refreshed
+// on a timer.
+func (d *Display) WithDedup(enabled bool) *Display {
+	d.dedup = enabled
 	return d
 }
 
 func (d *Display) Build() (*Display, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+
 	if d.font == nil {
-		f := basicfont.Face7x13
+		f, tf, err := fontFromEnv()
+		if err != nil {
+			log.Printf("using default font: %v", err)
+		}
+		if f == nil {
+			f = defaultFont()
+		}
+		if f == nil {
+			return nil, fmt.Errorf("no font configured and the default font is disabled by the nofont build tag")
+		}
 		lineHeight := f.Metrics().Height.Ceil()
 		d.font = f
+		d.ttf = tf
 		d.lineHeight = lineHeight
 	}
 	return d, nil
@@ -73,13 +518,46 @@ func (d *Display) Init() error {
 	d.buffer = make([]string, d.lines)
 
 	if d.driver == nil {
-		d.driver = NewRealSSD1306(d.busName)
+		driver := NewRealSSD1306(d.busName)
+		if d.deviceOpts != nil {
+			driver.WithOpts(*d.deviceOpts)
+		}
+		driver.WithColumnOffset(d.columnOffset)
+		driver.WithBusFrequency(d.busFrequency)
+		driver.WithExternalVCC(d.externalVCC)
+		d.driver = driver
+	}
+
+	if d.busLockPath != "" {
+		lock, err := lockFile(d.busLockPath, !d.busLockFailFast)
+		if err != nil {
+			return err
+		}
+		d.busLock = lock
 	}
 
 	if err := d.driver.Open(); err != nil {
+		d.releaseBusLock()
 		return fmt.Errorf("failed to initialize device: %w", err)
 	}
 
+	bounds := d.driver.Bounds()
+	if !d.viewport.Empty() && !d.viewport.In(bounds) {
+		d.driver.Close() //nolint:errcheck
+		d.releaseBusLock()
+		return fmt.Errorf("viewport %v is outside of display bounds %v", d.viewport, bounds)
+	}
+
+	d.fb = image1bit.NewVerticalLSB(bounds)
+	d.lastDraw = d.clock()
+	d.blank = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.backgroundCtx, d.cancelBackground = ctx, cancel
+	if d.screensaver != nil {
+		d.goBackground(ctx, d.runScreensaver)
+	}
+
 	d.initialized = true
 
 	return nil
@@ -87,11 +565,55 @@ func (d *Display) Init() error {
 
 func (d *Display) Close() error {
 	if d.initialized {
+		d.cancelBackground()
+		d.background.Wait()
+		defer d.releaseBusLock()
 		return d.driver.Close()
 	}
 	return nil
 }
 
+// releaseBusLock releases the lock taken for WithBusLockFile, if any.
+func (d *Display) releaseBusLock() {
+	if d.busLock != nil {
+		d.busLock.Close() //nolint:errcheck
+		d.busLock = nil
+	}
+}
+
+// Supports reports whether the driver provides the given optional
+// capability. It reports false for everything before Init if no driver was
+// set with WithDriver.
+func (d *Display) Supports(c Capability) bool {
+	var ok bool
+	switch c {
+	case CapContrast:
+		_, ok = d.driver.(ContrastSetter)
+	case CapInvert:
+		_, ok = d.driver.(Inverter)
+	case CapScroll:
+		_, ok = d.driver.(Scroller)
+	case CapViewer:
+		_, ok = d.driver.(ViewerWaiter)
+	}
+	return ok
+}
+
+// WaitForViewer blocks until someone is watching the display or ctx is
+// cancelled. With the simulator this means a browser is connected to the
+// live view; drivers for real hardware, which are always visible, return
+// immediately.
+func (d *Display) WaitForViewer(ctx context.Context) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	if waiter, ok := d.driver.(ViewerWaiter); ok {
+		return waiter.WaitForViewer(ctx)
+	}
+	return nil
+}
+
 func (d *Display) ClearLines() error {
 	if !d.initialized {
 		return fmt.Errorf("driver has not been initialized")
@@ -99,12 +621,20 @@ func (d *Display) ClearLines() error {
 	for i := range d.buffer {
 		d.buffer[i] = ""
 	}
-	return nil
+	clear(d.lineFaces)
+	clear(d.lineOffsets)
+	d.scrollNext = 0
+	d.history = nil
+	return d.changed()
 }
 
 func (d *Display) ClearScreen() error {
+	if d.fb != nil {
+		clear(d.fb.Pix)
+	}
+
 	img := image1bit.NewVerticalLSB(d.driver.Bounds())
-	if err := d.driver.Draw(d.driver.Bounds(), img, image.Point{}); err != nil {
+	if err := d.draw(img); err != nil {
 		return fmt.Errorf("failed to draw on display: %w", err)
 	}
 	return nil
@@ -119,8 +649,65 @@ func (d *Display) PrintLine(line uint, text string) error {
 		return fmt.Errorf("request to draw on line %d but display only has %d lines", line, len(d.buffer))
 	}
 
-	d.buffer[line] = text
-	return nil
+	if err := d.checkViewport(int(line) + 1); err != nil {
+		return err
+	}
+
+	d.buffer[line] = d.cleanLine(text)
+	d.resetLine(int(line))
+	return d.changed()
+}
+
+// SetLineBackground sets whether the background of the given line is lit.
+// With both background and foreground on, the line renders as a solid bar.
+func (d *Display) SetLineBackground(line uint, on bool) error {
+	return d.setLineColor(d.lineBackground, line, on)
+}
+
+// SetLineForeground sets whether the text of the given line is lit.
+func (d *Display) SetLineForeground(line uint, on bool) error {
+	return d.setLineColor(d.lineForeground, line, on)
+}
+
+func (d *Display) setLineColor(colors map[int]bool, line uint, on bool) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	if int(line) >= len(d.buffer) {
+		return fmt.Errorf("request to set color of line %d but display only has %d lines", line, len(d.buffer))
+	}
+
+	colors[int(line)] = on
+	return d.changed()
+}
+
+// lineColors returns the foreground and background colors of a line.
+func (d *Display) lineColors(line int) (fg, bg image1bit.Bit) {
+	fg, bg = image1bit.On, image1bit.Off
+	if d.invertedBackground {
+		fg, bg = bg, fg
+	}
+	if on, ok := d.lineForeground[line]; ok {
+		fg = image1bit.Bit(on)
+	}
+	if on, ok := d.lineBackground[line]; ok {
+		bg = image1bit.Bit(on)
+	}
+	return fg, bg
+}
+
+// PrintLineOffset prints text on the given line shifted down by yOffset
+// pixels, or up if it is negative, from the line's normal baseline. The text
+// is clipped to the text area.
+func (d *Display) PrintLineOffset(line uint, yOffset int, text string) error {
+	return d.batch(func() error {
+		if err := d.PrintLine(line, text); err != nil {
+			return err
+		}
+		d.lineOffsets[int(line)] = yOffset
+		return nil
+	})
 }
 
 func (d *Display) PrintLines(line uint, text []string) error {
@@ -132,11 +719,326 @@ func (d *Display) PrintLines(line uint, text []string) error {
 		return fmt.Errorf("text requires more than %d lines", len(d.buffer))
 	}
 
+	if err := d.checkViewport(int(line) + len(text)); err != nil {
+		return err
+	}
+
 	for i := range text {
-		d.buffer[int(line)+i] = text[i]
+		d.buffer[int(line)+i] = d.cleanLine(text[i])
+		d.resetLine(int(line) + i)
 	}
 
-	return nil
+	return d.changed()
+}
+
+// PrintLinesExact writes text starting at line, as PrintLines does, and blanks
+// every line after it to the end of the buffer so no stale content remains
+// below.
+func (d *Display) PrintLinesExact(line uint, text []string) error {
+	return d.batch(func() error {
+		if err := d.PrintLines(line, text); err != nil {
+			return err
+		}
+
+		for i := int(line) + len(text); i < len(d.buffer); i++ {
+			d.buffer[i] = ""
+			d.resetLine(i)
+		}
+
+		return nil
+	})
+}
+
+// PrintParagraph word-wraps text to the width of the text area and writes
+// the result to consecutive lines starting at start, returning the number of
+// lines used. If the wrapped text does not fit in the remaining lines,
+// nothing is written and the error wraps ErrTextTruncated. It returns an
+// error if the text area, less any line numbers, has no width left.
+func (d *Display) PrintParagraph(start uint, text string) (int, error) {
+	if !d.initialized {
+		return 0, fmt.Errorf("driver has not been initialized")
+	}
+
+	width := d.textArea().Dx() - d.gutterWidth()
+	if width <= 0 {
+		return 0, fmt.Errorf("no room for text: line width is %d pixels", width)
+	}
+
+	lines := wrapText(d.font, text, width, d.spacing)
+	if available := len(d.buffer) - int(start); len(lines) > available {
+		return 0, fmt.Errorf("%w: paragraph needs %d lines but only %d are available from line %d",
+			ErrTextTruncated, len(lines), max(available, 0), start)
+	}
+
+	if err := d.PrintLines(start, lines); err != nil {
+		return 0, err
+	}
+	return len(lines), nil
+}
+
+// Render replaces the whole text buffer with lines and updates the display.
+func (d *Display) Render(lines []string) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	if len(lines) > len(d.buffer) {
+		return fmt.Errorf("text requires %d lines but display only has %d lines", len(lines), len(d.buffer))
+	}
+
+	if err := d.checkViewport(len(lines)); err != nil {
+		return err
+	}
+
+	defer d.suspendAutoUpdate()()
+
+	if err := d.ClearLines(); err != nil {
+		return err
+	}
+
+	if err := d.PrintLines(0, lines); err != nil {
+		return err
+	}
+
+	return d.Update()
+}
+
+// Resize changes the number of text lines after Init. Existing lines are
+// preserved where they still fit; lines beyond the new count are discarded.
+func (d *Display) Resize(lines uint) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	if available := d.linesInHeight(d.textArea().Dy()); int(lines) > available {
+		return fmt.Errorf("cannot resize to %d lines: display only fits %d lines", lines, available)
+	}
+
+	for i := int(lines); i < len(d.buffer); i++ {
+		d.resetLine(i)
+		delete(d.lineBackground, i)
+		delete(d.lineForeground, i)
+	}
+	buffer := make([]string, lines)
+	copy(buffer, d.buffer)
+	d.buffer = buffer
+	d.lines = lines
+	d.scrollNext = min(d.scrollNext, int(lines))
+	return d.changed()
+}
+
+// PrintLineScroll appends text as a new line. Lines fill the display from the
+// top, and once it is full earlier lines scroll up to make room. With
+// WithReverseScroll the new line always goes on the bottom line instead.
+// Lines reserved with WithHeaderLines do not scroll.
+func (d *Display) PrintLineScroll(text string) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	top, n := d.scrollRegion()
+	if top >= n {
+		return fmt.Errorf("display has no lines to scroll")
+	}
+
+	d.scrollNext = max(d.scrollNext, top)
+	if d.reverseScroll || d.scrollNext >= n {
+		d.scrollUp(top, n)
+		d.scrollNext = n - 1
+	}
+
+	d.buffer[d.scrollNext] = d.cleanLine(text)
+	d.resetLine(d.scrollNext)
+	d.scrollNext++
+	return d.changed()
+}
+
+// Append adds lines below those added by earlier calls. Without
+// WithAutoScrollScreen it returns an error once the lines no longer fit. With
+// it, every appended line is kept and the display shows the most recent
+// lines that fit. Lines reserved with WithHeaderLines are left alone.
+func (d *Display) Append(lines ...string) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	top, end := d.scrollRegion()
+	n := max(end-top, 0)
+	if !d.autoScrollScreen && len(d.history)+len(lines) > n {
+		return fmt.Errorf("text requires %d lines but display only has %d lines", len(d.history)+len(lines), n)
+	}
+
+	for _, line := range lines {
+		d.history = append(d.history, d.cleanLine(line))
+	}
+	window := d.history[max(0, len(d.history)-n):]
+	for i := range n {
+		d.buffer[top+i] = ""
+		if i < len(window) {
+			d.buffer[top+i] = window[i]
+		}
+		d.resetLine(top + i)
+	}
+
+	return d.changed()
+}
+
+// visibleLines returns the number of buffer lines that fit in the viewport.
+func (d *Display) visibleLines() int {
+	n := len(d.buffer)
+	if !d.viewport.Empty() {
+		n = min(n, d.linesInHeight(d.viewport.Dy()))
+	}
+	return n
+}
+
+// scrollRegion returns the first line that scrolls, after any header lines,
+// and the end of the visible lines.
+func (d *Display) scrollRegion() (top, end int) {
+	end = d.visibleLines()
+	return min(int(d.headerLines), end), end
+}
+
+// Write implements io.Writer. Each line of p is added with PrintLineScroll;
+// a trailing newline does not start a new line. Call Update to show the
+// result.
+func (d *Display) Write(p []byte) (int, error) {
+	err := d.batch(func() error {
+		for _, line := range strings.Split(strings.TrimSuffix(string(p), "\n"), "\n") {
+			if err := d.PrintLineScroll(line); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// scrollUp moves lines top to n-1 of the buffer up by one, discarding line
+// top and leaving line n-1 blank.
+func (d *Display) scrollUp(top, n int) {
+	copy(d.buffer[top:n-1], d.buffer[top+1:n])
+	d.buffer[n-1] = ""
+
+	d.lineFaces = shiftLines(d.lineFaces, top, n)
+	d.lineOffsets = shiftLines(d.lineOffsets, top, n)
+	d.lineBackground = shiftLines(d.lineBackground, top, n)
+	d.lineForeground = shiftLines(d.lineForeground, top, n)
+}
+
+// shiftLines returns a copy of a per-line map with the entries for lines top
+// to n-1 moved up by one, dropping the entry for line top.
+func shiftLines[V any](m map[int]V, top, n int) map[int]V {
+	shifted := make(map[int]V, len(m))
+	for i, v := range m {
+		switch {
+		case i < top || i >= n:
+			shifted[i] = v
+		case i > top:
+			shifted[i-1] = v
+		}
+	}
+	return shifted
+}
+
+// cleanLine prepares text for storing in the buffer as configured with
+// WithSanitizeUTF8, WithTrimLines and WithTrimLeadingSpace.
+func (d *Display) cleanLine(text string) string {
+	if d.sanitizeUTF8 {
+		text = strings.ToValidUTF8(text, string(utf8.RuneError))
+	}
+	if d.trimTrailing {
+		text = strings.TrimRightFunc(text, unicode.IsSpace)
+	}
+	if d.trimLeading {
+		text = strings.TrimLeftFunc(text, unicode.IsSpace)
+	}
+	return text
+}
+
+// resetLine drops the font and offset set for a line when its text is
+// replaced.
+func (d *Display) resetLine(line int) {
+	delete(d.lineFaces, line)
+	delete(d.lineOffsets, line)
+}
+
+// PrintTime prints the current time, formatted using layout, on the given
+// line.
+func (d *Display) PrintTime(line uint, layout string) error {
+	return d.PrintLine(line, d.clock().Format(layout))
+}
+
+// PrintAt overwrites the text of a line starting at character column col,
+// leaving the rest of the line intact. Columns are character cells as wide as
+// the advance of the active font, so with a proportional font the text still
+// starts col cells from the left: it replaces the characters from the first
+// one at or after that position for as far as the new text reaches. Short
+// lines are padded with spaces up to col.
+func (d *Display) PrintAt(line uint, col int, text string) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	if int(line) >= len(d.buffer) {
+		return fmt.Errorf("request to draw on line %d but display only has %d lines", line, len(d.buffer))
+	}
+
+	if col < 0 {
+		return fmt.Errorf("invalid column %d", col)
+	}
+
+	x := col * d.cellWidth()
+	current := []rune(d.buffer[line])
+	if short, space := x-d.textWidth(string(current)), d.textWidth(" "); short > 0 && space > 0 {
+		current = append(current, []rune(strings.Repeat(" ", (short+space-1)/space))...)
+	}
+
+	start := d.runeIndexAt(current, x)
+	end := d.runeIndexAt(current, x+d.textWidth(text))
+	updated := append(current[:start:start], []rune(text)...)
+	updated = append(updated, current[end:]...)
+
+	return d.PrintLine(line, string(updated))
+}
+
+// runeIndexAt returns the index of the first of runes that starts at or
+// after x pixels from the start of the text in the active font, or
+// len(runes) if the text is narrower than that.
+func (d *Display) runeIndexAt(runes []rune, x int) int {
+	for i := range runes {
+		if d.textWidth(string(runes[:i])) >= x {
+			return i
+		}
+	}
+	return len(runes)
+}
+
+// EraseToEndOfLine blanks a line from character column fromCol to its end,
+// like a terminal's erase in line. Columns are counted as in PrintAt.
+func (d *Display) EraseToEndOfLine(line uint, fromCol int) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	if int(line) >= len(d.buffer) {
+		return fmt.Errorf("request to erase line %d but display only has %d lines", line, len(d.buffer))
+	}
+
+	if fromCol < 0 {
+		return fmt.Errorf("invalid column %d", fromCol)
+	}
+
+	current := []rune(d.buffer[line])
+	end := d.runeIndexAt(current, fromCol*d.cellWidth())
+	if end >= len(current) {
+		return nil
+	}
+
+	return d.PrintLine(line, string(current[:end]))
 }
 
 func (d *Display) Update() error {
@@ -144,27 +1046,295 @@ func (d *Display) Update() error {
 		return fmt.Errorf("driver has not been initialized")
 	}
 
+	img := d.render()
+	if !d.alwaysDraw && d.isBlank() && blankFrame(img) {
+		d.dirty = false
+		return nil
+	}
+
+	if err := d.draw(img); err != nil {
+		return fmt.Errorf("failed to draw on display: %w", err)
+	}
+
+	d.dirty = false
+	return nil
+}
+
+// String returns the text buffer with lines joined by newlines, for logging
+// what is on the display. It returns an empty string before Init.
+func (d *Display) String() string {
+	return strings.Join(d.buffer, "\n")
+}
+
+// ForceRefresh sends the current content to the driver even if nothing has
+// changed, bypassing the checks that let Update skip redundant draws. Use it
+// to restore the panel after a glitch or power loss.
+func (d *Display) ForceRefresh() error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	if err := d.drawFrame(d.render(), false); err != nil {
+		return fmt.Errorf("failed to draw on display: %w", err)
+	}
+
+	d.dirty = false
+	return nil
+}
+
+// Dirty reports whether the text buffer or framebuffer has changed since the
+// last successful Update, so callers can skip redundant updates.
+func (d *Display) Dirty() bool {
+	return d.dirty
+}
+
+// changed is called after every change to the text buffer or framebuffer,
+// and updates the display if auto-update is enabled.
+func (d *Display) changed() error {
+	d.dirty = true
+	if !d.autoUpdate || d.suspended > 0 {
+		return nil
+	}
+	return d.Update()
+}
+
+// suspendAutoUpdate holds back auto-update until the returned function is
+// called.
+func (d *Display) suspendAutoUpdate() func() {
+	d.suspended++
+	return func() { d.suspended-- }
+}
+
+// batch runs fn with auto-update suspended, so that a method built from
+// several changes results in at most one draw.
+func (d *Display) batch(fn func() error) error {
+	restore := d.suspendAutoUpdate()
+	err := fn()
+	restore()
+	if err != nil {
+		return err
+	}
+	return d.changed()
+}
+
+// draw sends a full frame to the driver.
+func (d *Display) draw(img image.Image) error {
+	return d.drawFrame(img, d.dedup)
+}
+
+// drawFrame sends a full frame to the driver. With dedup, a frame identical
+// to the last one sent is skipped.
+func (d *Display) drawFrame(img image.Image, dedup bool) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.lastDraw = d.clock()
+	d.shown = img
+	if d.alerting {
+		return nil
+	}
+
+	// A running hardware scroll has cleared haveLastFrame, so dedup never
+	// skips the draw that stops it.
+	hash, hashed := frameHash(img)
+	if dedup && hashed && d.haveLastFrame && hash == d.lastFrame {
+		return nil
+	}
+
+	d.blank = false
+	d.haveLastFrame = false
+	if err := d.drawLocked(img); err != nil {
+		return err
+	}
+	d.blank = blankFrame(img)
+	d.lastFrame, d.haveLastFrame = hash, hashed
+	return nil
+}
+
+// frameHash returns an FNV-1a hash of a 1-bit frame. It returns false for
+// other kinds of image.
+func frameHash(img image.Image) (uint64, bool) {
+	frame, ok := img.(*image1bit.VerticalLSB)
+	if !ok {
+		return 0, false
+	}
+	h := fnv.New64a()
+	h.Write(frame.Pix) //nolint:errcheck
+	return h.Sum64(), true
+}
+
+// drawLocked stops any hardware scroll and sends a frame to the driver,
+// applying the draw timeout. The caller must hold the mutex.
+func (d *Display) drawLocked(img image.Image) error {
+	if err := d.stopScrollLocked(); err != nil {
+		return err
+	}
+
+	if d.drawTimeout <= 0 {
+		return d.driver.Draw(d.driver.Bounds(), img, image.Point{})
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- d.driver.Draw(d.driver.Bounds(), img, image.Point{})
+	}()
+
+	timer := time.NewTimer(d.drawTimeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("%w after %v", ErrDrawTimeout, d.drawTimeout)
+	}
+}
+
+// isBlank reports whether the panel is known to be showing an empty frame.
+func (d *Display) isBlank() bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.blank
+}
+
+// blankFrame reports whether img is a 1-bit frame with no lit pixels.
+func blankFrame(img image.Image) bool {
+	frame, ok := img.(*image1bit.VerticalLSB)
+	if !ok {
+		return false
+	}
+	for _, b := range frame.Pix {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// goBackground runs fn in a goroutine that Close waits for after
+// cancelling ctx.
+func (d *Display) goBackground(ctx context.Context, fn func(context.Context)) {
+	d.background.Add(1)
+	go func() {
+		defer d.background.Done()
+		fn(ctx)
+	}()
+}
+
+// render composites the text buffer over the retained framebuffer and
+// returns the resulting frame. The framebuffer itself is left unmodified.
+func (d *Display) render() *image1bit.VerticalLSB {
 	img := image1bit.NewVerticalLSB(d.driver.Bounds())
+	copy(img.Pix, d.fb.Pix)
+	if d.invertedBackground {
+		for i := range img.Pix {
+			img.Pix[i] = ^img.Pix[i]
+		}
+	}
+
+	area := d.textArea()
 	screen := font.Drawer{
-		Dst:  img,
+		Dst:  clippedImage{Image: img, clip: area},
 		Src:  &image.Uniform{image1bit.On},
 		Face: d.font,
 	}
 
+	gutter := d.gutterWidth()
 	for i, textLine := range d.buffer {
-		screen.Dot = fixed.P(0, d.lineHeight*(1+i)-d.font.Metrics().Descent.Round())
-		screen.DrawString(textLine)
+		fg, bg := d.lineColors(i)
+		if bg != image1bit.Bit(d.invertedBackground) {
+			fillRect(img, d.lineRect(i), bg)
+		}
+		screen.Src = &image.Uniform{fg}
+
+		face := d.font
+		if f, ok := d.lineFaces[i]; ok {
+			face = f
+		}
+
+		y := area.Min.Y + d.lineBaseline(i, face) + d.lineOffsets[i]
+		if gutter > 0 {
+			number := strconv.Itoa(i + 1)
+			screen.Face = d.font
+			screen.Dot = fixed.P(area.Min.X+gutter-d.cellWidth()-font.MeasureString(d.font, number).Ceil(), y)
+			screen.DrawString(number)
+		}
+
+		screen.Face = face
+		screen.Dot = fixed.P(area.Min.X+gutter, y)
+		d.drawString(&screen, d.replaceMissingGlyphs(screen.Face, textLine))
 	}
-	if err := d.driver.Draw(d.driver.Bounds(), img, image.Point{}); err != nil {
-		return fmt.Errorf("failed to draw on display: %w", err)
+
+	if d.screenBorder {
+		strokeRect(img, img.Bounds(), image1bit.Bit(!d.invertedBackground))
+	}
+
+	xorRect(img, d.cursor)
+
+	return img
+}
+
+// textArea returns the region of the display used for rendering text lines.
+func (d *Display) textArea() image.Rectangle {
+	area := d.driver.Bounds()
+	if !d.viewport.Empty() {
+		area = d.viewport
 	}
+	if d.screenBorder {
+		area = area.Intersect(d.driver.Bounds().Inset(borderWidth))
+	}
+	return area
+}
 
+// checkViewport returns an error if a viewport is configured and the given
+// number of lines does not fit within it.
+func (d *Display) checkViewport(lines int) error {
+	if d.viewport.Empty() {
+		return nil
+	}
+	if available := d.linesInHeight(d.viewport.Dy()); lines > available {
+		return fmt.Errorf("text requires %d lines but viewport only fits %d lines", lines, available)
+	}
 	return nil
 }
 
-func (d *Display) SetFont(f font.Face) {
+// baseline returns the y coordinate of the baseline of the given text line.
+// The first baseline is low enough for the font's full ascent to fit, which
+// for some large fonts is more than the line height minus the descent.
+func (d *Display) baseline(line int) int {
+	metrics := d.font.Metrics()
+	first := max(d.lineHeight-metrics.Descent.Round(), metrics.Ascent.Ceil())
+	return first + d.lineHeight*line
+}
+
+// lineBaseline returns the y coordinate of the baseline of the given text
+// line when it is drawn with face. A face taller than the active font, such
+// as one chosen by PrintLineFit, is moved down so that its ascent starts at
+// the top of the line instead of being clipped or running into the line
+// above.
+func (d *Display) lineBaseline(line int, face font.Face) int {
+	top := d.baseline(line) + d.font.Metrics().Descent.Round() - d.lineHeight
+	return max(d.baseline(line), top+face.Metrics().Ascent.Ceil())
+}
+
+func (d *Display) SetFont(f font.Face) error {
+	if err := validateFont(f); err != nil {
+		return err
+	}
 	d.font = f
+	d.ttf = nil
 	d.lineHeight = f.Metrics().Height.Ceil()
+	return nil
+}
+
+func validateFont(f font.Face) error {
+	if f == nil {
+		return fmt.Errorf("font must not be nil")
+	}
+	if height := f.Metrics().Height.Ceil(); height <= 0 {
+		return fmt.Errorf("font has invalid line height %d; check the font size and DPI", height)
+	}
+	return nil
 }
 
 func (d *Display) ShowImage(img image.Image) error {
@@ -172,44 +1342,138 @@ func (d *Display) ShowImage(img image.Image) error {
 		return fmt.Errorf("driver has not been initialized")
 	}
 
-	bounds := d.driver.Bounds()
-	displayImg := image1bit.NewVerticalLSB(bounds)
-
-	imgBounds := img.Bounds()
-	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			srcX := imgBounds.Min.X + x
-			srcY := imgBounds.Min.Y + y
-			if srcX < imgBounds.Max.X && srcY < imgBounds.Max.Y {
-				c := img.At(srcX, srcY)
-				gray := color.GrayModel.Convert(c).(color.Gray)
-				if gray.Y > 128 {
-					displayImg.Set(x, y, image1bit.On)
-				} else {
-					displayImg.Set(x, y, image1bit.Off)
-				}
-			}
-		}
-	}
+	return d.showFrame(d.convertImage(img))
+}
 
-	if err := d.driver.Draw(bounds, displayImg, image.Point{}); err != nil {
+// showFrame draws an already converted image on the display.
+func (d *Display) showFrame(frame *image1bit.VerticalLSB) error {
+	if err := d.draw(frame); err != nil {
 		return fmt.Errorf("failed to draw image on display: %w", err)
 	}
 
 	return nil
 }
 
+// ShowRaw draws a frame of SSD1306 page-packed bytes straight to the display:
+// one byte per column per page of 8 rows, least significant bit at the top,
+// pages in order from the top of the display.
+func (d *Display) ShowRaw(data []byte) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	frame := image1bit.NewVerticalLSB(d.driver.Bounds())
+	if len(data) != len(frame.Pix) {
+		return fmt.Errorf("raw frame has %d bytes but display needs %d", len(data), len(frame.Pix))
+	}
+	copy(frame.Pix, data)
+
+	return d.showFrame(frame)
+}
+
+// isLit reports whether a source pixel should be lit on the display.
+func isLit(c color.Color) image1bit.Bit {
+	return threshold(luma(c))
+}
+
+// toBitmap converts img to a 1-bit image with the same dimensions, with its
+// origin at (0, 0).
+func toBitmap(img image.Image) *image1bit.VerticalLSB {
+	b := img.Bounds()
+	bitmap := image1bit.NewVerticalLSB(image.Rect(0, 0, b.Dx(), b.Dy()))
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			bitmap.SetBit(x-b.Min.X, y-b.Min.Y, isLit(img.At(x, y)))
+		}
+	}
+	return bitmap
+}
+
 func (d *Display) ShowImageFromFile(filename string) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
 	file, err := os.Open(filename)
 	if err != nil {
 		return fmt.Errorf("failed to open image file: %w", err)
 	}
 	defer file.Close() //nolint:errcheck
 
-	img, _, err := image.Decode(file)
+	var modTime time.Time
+	if d.imageCache != nil {
+		info, err := file.Stat()
+		if err != nil {
+			return fmt.Errorf("failed to stat image file: %w", err)
+		}
+		modTime = info.ModTime()
+
+		if cached, ok := d.imageCache[filename]; ok && cached.modTime.Equal(modTime) {
+			return d.showFrame(cached.frame)
+		}
+	}
+
+	img, err := d.decodeLimited(file)
+	if err != nil {
+		return err
+	}
+
+	frame := d.convertImage(img)
+	if d.imageCache != nil {
+		d.imageCache[filename] = cachedImage{modTime: modTime, frame: frame}
+	}
+
+	return d.showFrame(frame)
+}
+
+// ShowImageFromReader decodes an image from r and shows it as ShowImage
+// does.
+func (d *Display) ShowImageFromReader(r io.Reader) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	img, err := d.decodeLimited(r)
 	if err != nil {
-		return fmt.Errorf("failed to decode image: %w", err)
+		return err
 	}
 
 	return d.ShowImage(img)
 }
+
+// decodeLimited decodes an image, enforcing the limits set with
+// WithMaxImageBytes and WithMaxImageDimensions. The image header is checked
+// before the image itself is decoded. Without a byte limit the image is
+// decoded as it is read rather than read into memory first.
+func (d *Display) decodeLimited(r io.Reader) (image.Image, error) {
+	if d.maxImageBytes > 0 {
+		data, err := io.ReadAll(io.LimitReader(r, d.maxImageBytes+1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read image: %w", err)
+		}
+		if int64(len(data)) > d.maxImageBytes {
+			return nil, fmt.Errorf("image is larger than the limit of %d bytes", d.maxImageBytes)
+		}
+		r = bytes.NewReader(data)
+	}
+
+	if limit := d.maxImageSize; limit != (image.Point{}) {
+		// Keep the bytes read while checking the header so that they can
+		// be read again when decoding the image itself.
+		var header bytes.Buffer
+		config, _, err := image.DecodeConfig(io.TeeReader(r, &header))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode image: %w", err)
+		}
+		if (limit.X > 0 && config.Width > limit.X) || (limit.Y > 0 && config.Height > limit.Y) {
+			return nil, fmt.Errorf("image of %dx%d pixels exceeds the limit of %dx%d", config.Width, config.Height, limit.X, limit.Y)
+		}
+		r = io.MultiReader(&header, r)
+	}
+
+	img, _, err := decodeImage(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, nil
+}
@@ -1,15 +1,22 @@
 package display
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"image"
 	"image/color"
+	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/larsks/display1306/v2/display/fakedriver"
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/basicfont"
+	"periph.io/x/devices/v3/ssd1306/image1bit"
 )
 
 // Call represents a method call on the mock
@@ -21,6 +28,7 @@ type Call struct {
 // Enhanced FakeSSD1306 for testing with call tracking
 type TrackedFakeSSD1306 struct {
 	*fakedriver.FakeSSD1306
+	mutex        sync.Mutex
 	Calls        []Call
 	ErrorOnOpen  bool
 	ErrorOnClose bool
@@ -35,7 +43,7 @@ func NewTrackedFakeSSD1306() *TrackedFakeSSD1306 {
 }
 
 func (t *TrackedFakeSSD1306) Open() error {
-	t.Calls = append(t.Calls, Call{Method: "Open", Args: nil})
+	t.record(Call{Method: "Open", Args: nil})
 	if t.ErrorOnOpen {
 		return fmt.Errorf("mock open error")
 	}
@@ -43,7 +51,7 @@ func (t *TrackedFakeSSD1306) Open() error {
 }
 
 func (t *TrackedFakeSSD1306) Close() error {
-	t.Calls = append(t.Calls, Call{Method: "Close", Args: nil})
+	t.record(Call{Method: "Close", Args: nil})
 	if t.ErrorOnClose {
 		return fmt.Errorf("mock close error")
 	}
@@ -51,20 +59,28 @@ func (t *TrackedFakeSSD1306) Close() error {
 }
 
 func (t *TrackedFakeSSD1306) Bounds() image.Rectangle {
-	t.Calls = append(t.Calls, Call{Method: "Bounds", Args: nil})
+	t.record(Call{Method: "Bounds", Args: nil})
 	return t.FakeSSD1306.Bounds()
 }
 
 func (t *TrackedFakeSSD1306) Draw(r image.Rectangle, src image.Image, sp image.Point) error {
-	t.Calls = append(t.Calls, Call{Method: "Draw", Args: []interface{}{r, src, sp}})
+	t.record(Call{Method: "Draw", Args: []interface{}{r, src, sp}})
 	if t.ErrorOnDraw {
 		return fmt.Errorf("mock draw error")
 	}
 	return nil
 }
 
+func (t *TrackedFakeSSD1306) record(call Call) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.Calls = append(t.Calls, call)
+}
+
 // Test helper functions
 func (t *TrackedFakeSSD1306) WasCalled(method string) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
 	for _, call := range t.Calls {
 		if call.Method == method {
 			return true
@@ -74,6 +90,8 @@ func (t *TrackedFakeSSD1306) WasCalled(method string) bool {
 }
 
 func (t *TrackedFakeSSD1306) CallCount(method string) int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
 	count := 0
 	for _, call := range t.Calls {
 		if call.Method == method {
@@ -84,6 +102,8 @@ func (t *TrackedFakeSSD1306) CallCount(method string) int {
 }
 
 func (t *TrackedFakeSSD1306) LastDrawArgs() (image.Rectangle, image.Image, image.Point) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
 	for i := len(t.Calls) - 1; i >= 0; i-- {
 		if t.Calls[i].Method == "Draw" && len(t.Calls[i].Args) == 3 {
 			return t.Calls[i].Args[0].(image.Rectangle),
@@ -94,6 +114,19 @@ func (t *TrackedFakeSSD1306) LastDrawArgs() (image.Rectangle, image.Image, image
 	return image.Rectangle{}, nil, image.Point{}
 }
 
+// DrawnImages returns the source image of every Draw call, in order.
+func (t *TrackedFakeSSD1306) DrawnImages() []image.Image {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	var images []image.Image
+	for _, call := range t.Calls {
+		if call.Method == "Draw" {
+			images = append(images, call.Args[1].(image.Image))
+		}
+	}
+	return images
+}
+
 // Test assertion helpers
 func assertNoError(t *testing.T, err error) {
 	t.Helper()
@@ -118,6 +151,40 @@ func assertMethodCalled(t *testing.T, mock *TrackedFakeSSD1306, method string) {
 	}
 }
 
+// newTestDisplay builds and initializes the given display using a tracked
+// fake driver.
+// newTestBuilder returns a builder with the font set explicitly, so that
+// tests do not depend on the default font that the nofont tag removes.
+func newTestBuilder() *Display {
+	return NewDisplay().WithFont(basicfont.Face7x13)
+}
+
+func newTestDisplay(t *testing.T, builder *Display) (*Display, *TrackedFakeSSD1306) {
+	t.Helper()
+	mock := NewTrackedFakeSSD1306()
+	display, err := builder.WithBusName("/dev/i2c-0").WithDriver(mock).Build()
+	if err != nil {
+		t.Fatalf("Failed to build display: %v", err)
+	}
+	if err := display.Init(); err != nil {
+		t.Fatalf("Failed to initialize display: %v", err)
+	}
+	return display, mock
+}
+
+// countOn returns the number of lit pixels of img within r.
+func countOn(img image.Image, r image.Rectangle) int {
+	count := 0
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if img.At(x, y) == image1bit.On {
+				count++
+			}
+		}
+	}
+	return count
+}
+
 func TestNewDisplay(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -141,7 +208,7 @@ func TestNewDisplay(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			display, err := NewDisplay().WithBusName(tt.busName).WithDriver(tt.dev).Build()
+			display, err := newTestBuilder().WithBusName(tt.busName).WithDriver(tt.dev).Build()
 			assertNoError(t, err)
 			_ = display.Init()
 
@@ -196,22 +263,6 @@ func TestDisplay_WithFont(t *testing.T) {
 	}
 }
 
-func TestDisplay_Build_WithDefaultFont(t *testing.T) {
-	display := NewDisplay()
-	built, err := display.Build()
-	assertNoError(t, err)
-
-	// Should have default font set
-	if built.font == nil {
-		t.Error("Expected default font to be set")
-	}
-
-	expectedHeight := basicfont.Face7x13.Metrics().Height.Ceil()
-	if built.lineHeight != expectedHeight {
-		t.Errorf("Expected lineHeight to be %d, got %d", expectedHeight, built.lineHeight)
-	}
-}
-
 func TestDisplay_Build_WithCustomFont(t *testing.T) {
 	customFont := basicfont.Face7x13
 	display := NewDisplay().WithFont(customFont)
@@ -258,7 +309,7 @@ func TestDisplay_Init(t *testing.T) {
 			mock := NewTrackedFakeSSD1306()
 			tt.setupMock(mock)
 
-			display, err := NewDisplay().WithBusName("/dev/i2c-0").WithDriver(mock).Build()
+			display, err := newTestBuilder().WithBusName("/dev/i2c-0").WithDriver(mock).Build()
 			assertNoError(t, err)
 
 			err = display.Init()
@@ -300,7 +351,7 @@ func TestDisplay_Close(t *testing.T) {
 			mock := NewTrackedFakeSSD1306()
 			mock.ErrorOnClose = tt.shouldError
 
-			display, err := NewDisplay().WithBusName("/dev/i2c-0").WithDriver(mock).Build()
+			display, err := newTestBuilder().WithBusName("/dev/i2c-0").WithDriver(mock).Build()
 			assertNoError(t, err)
 			err = display.Init()
 			assertNoError(t, err)
@@ -318,9 +369,107 @@ func TestDisplay_Close(t *testing.T) {
 	}
 }
 
+// minimalSSD1306 hides every optional capability of the wrapped driver,
+// exposing only the SSD1306 interface.
+type minimalSSD1306 struct {
+	SSD1306
+}
+
+// newMinimalDisplay returns an initialized display whose driver has no
+// optional capabilities.
+func newMinimalDisplay(t *testing.T) *Display {
+	t.Helper()
+	display, err := newTestBuilder().WithDriver(&minimalSSD1306{NewTrackedFakeSSD1306()}).Build()
+	assertNoError(t, err)
+	assertNoError(t, display.Init())
+	return display
+}
+
+// fullSSD1306 is a tracked fake that implements every optional driver
+// capability.
+type fullSSD1306 struct {
+	*scrollSSD1306
+}
+
+func (f *fullSSD1306) SetContrast(level byte) error {
+	f.record(Call{Method: "SetContrast", Args: []interface{}{level}})
+	return nil
+}
+
+func (f *fullSSD1306) Invert(blackOnWhite bool) error {
+	f.record(Call{Method: "Invert", Args: []interface{}{blackOnWhite}})
+	return nil
+}
+
+func TestDisplay_Supports(t *testing.T) {
+	all := []Capability{CapContrast, CapInvert, CapScroll, CapViewer}
+
+	minimal := newMinimalDisplay(t)
+	for _, c := range all {
+		if minimal.Supports(c) {
+			t.Errorf("Expected minimal fake not to support capability %d", c)
+		}
+	}
+
+	simulator, _ := newTestDisplay(t, newTestBuilder())
+	for c, want := range map[Capability]bool{CapContrast: true, CapInvert: true, CapScroll: false, CapViewer: true} {
+		if got := simulator.Supports(c); got != want {
+			t.Errorf("Expected simulator Supports(%d) to be %v, got %v", c, want, got)
+		}
+	}
+
+	driver := &fullSSD1306{&scrollSSD1306{NewTrackedFakeSSD1306()}}
+	full, err := newTestBuilder().WithDriver(driver).Build()
+	assertNoError(t, err)
+	for _, c := range all {
+		if !full.Supports(c) {
+			t.Errorf("Expected full fake to support capability %d", c)
+		}
+	}
+
+	// Without a driver nothing is supported, rather than panicking.
+	unset, err := newTestBuilder().Build()
+	assertNoError(t, err)
+	for _, c := range all {
+		if unset.Supports(c) {
+			t.Errorf("Expected no support for capability %d without a driver", c)
+		}
+	}
+}
+
+func TestDisplay_WaitForViewer(t *testing.T) {
+	fake := fakedriver.NewFakeSSD1306().WithListenAddress("127.0.0.1").WithPort(0)
+	display, err := newTestBuilder().WithDriver(fake).Build()
+	assertNoError(t, err)
+	assertNoError(t, display.Init())
+	defer display.Close() //nolint:errcheck
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := display.WaitForViewer(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected WaitForViewer to time out without a viewer, got %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- display.WaitForViewer(context.Background()) }()
+
+	resp, err := http.Get("http://" + fake.Addr() + "/events")
+	if err != nil {
+		t.Fatalf("Failed to connect to event stream: %v", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	select {
+	case err := <-done:
+		assertNoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("Expected WaitForViewer to return once a viewer connected")
+	}
+}
+
 func TestDisplay_ClearLines(t *testing.T) {
 	mock := NewTrackedFakeSSD1306()
-	display, err := NewDisplay().WithBusName("/dev/i2c-0").WithDriver(mock).Build()
+	display, err := newTestBuilder().WithBusName("/dev/i2c-0").WithDriver(mock).Build()
 	assertNoError(t, err)
 
 	// Initialize the display to set up the buffer
@@ -345,7 +494,7 @@ func TestDisplay_ClearLines(t *testing.T) {
 
 func TestDisplay_PrintLine(t *testing.T) {
 	mock := NewTrackedFakeSSD1306()
-	display, err := NewDisplay().WithBusName("/dev/i2c-0").WithDriver(mock).Build()
+	display, err := newTestBuilder().WithBusName("/dev/i2c-0").WithDriver(mock).Build()
 	assertNoError(t, err)
 
 	// Initialize the display
@@ -408,7 +557,7 @@ func TestDisplay_PrintLine(t *testing.T) {
 
 func TestDisplay_PrintLines(t *testing.T) {
 	mock := NewTrackedFakeSSD1306()
-	display, err := NewDisplay().WithBusName("/dev/i2c-0").WithDriver(mock).Build()
+	display, err := newTestBuilder().WithBusName("/dev/i2c-0").WithDriver(mock).Build()
 	assertNoError(t, err)
 
 	// Initialize the display
@@ -519,7 +668,7 @@ func TestDisplay_Update(t *testing.T) {
 			mock := NewTrackedFakeSSD1306()
 			mock.ErrorOnDraw = tt.mockShouldErr
 
-			display, err := NewDisplay().WithBusName("/dev/i2c-0").WithDriver(mock).Build()
+			display, err := newTestBuilder().WithBusName("/dev/i2c-0").WithDriver(mock).Build()
 			assertNoError(t, err)
 
 			// Initialize and setup buffer
@@ -553,7 +702,7 @@ func TestDisplay_Update(t *testing.T) {
 
 func TestDisplay_MethodsFailWithoutInit(t *testing.T) {
 	mock := NewTrackedFakeSSD1306()
-	display, err := NewDisplay().WithBusName("/dev/i2c-0").WithDriver(mock).Build()
+	display, err := newTestBuilder().WithBusName("/dev/i2c-0").WithDriver(mock).Build()
 	assertNoError(t, err)
 
 	// Test that all methods fail when Init() hasn't been called
@@ -608,7 +757,7 @@ func TestDisplay_MethodsFailWithoutInit(t *testing.T) {
 func TestDisplay_Integration(t *testing.T) {
 	// Integration test that exercises the full workflow
 	mock := NewTrackedFakeSSD1306()
-	display, err := NewDisplay().WithBusName("/dev/i2c-0").WithDriver(mock).Build()
+	display, err := newTestBuilder().WithBusName("/dev/i2c-0").WithDriver(mock).Build()
 	assertNoError(t, err)
 
 	// Initialize
@@ -660,7 +809,7 @@ func TestDisplay_FontIntegration(t *testing.T) {
 
 	// Test with custom font
 	customFont := basicfont.Face7x13
-	display, err := NewDisplay().
+	display, err := newTestBuilder().
 		WithBusName("/dev/i2c-0").
 		WithDriver(mock).
 		WithFont(customFont).
@@ -728,7 +877,7 @@ func (t *TestImage) Set(x, y int, c color.Color) {
 
 func TestDisplay_ShowImage_SmallImage(t *testing.T) {
 	mock := NewTrackedFakeSSD1306()
-	display, err := NewDisplay().WithBusName("/dev/i2c-0").WithDriver(mock).Build()
+	display, err := newTestBuilder().WithBusName("/dev/i2c-0").WithDriver(mock).Build()
 	assertNoError(t, err)
 
 	if err := display.Init(); err != nil {
@@ -750,7 +899,7 @@ func TestDisplay_ShowImage_SmallImage(t *testing.T) {
 
 func TestDisplay_ShowImage_LargeImageCropped(t *testing.T) {
 	mock := NewTrackedFakeSSD1306()
-	display, err := NewDisplay().WithBusName("/dev/i2c-0").WithDriver(mock).Build()
+	display, err := newTestBuilder().WithBusName("/dev/i2c-0").WithDriver(mock).Build()
 	assertNoError(t, err)
 
 	if err := display.Init(); err != nil {
@@ -780,7 +929,7 @@ func TestDisplay_ShowImage_LargeImageCropped(t *testing.T) {
 
 func TestDisplay_ShowImage_ExactSizeImage(t *testing.T) {
 	mock := NewTrackedFakeSSD1306()
-	display, err := NewDisplay().WithBusName("/dev/i2c-0").WithDriver(mock).Build()
+	display, err := newTestBuilder().WithBusName("/dev/i2c-0").WithDriver(mock).Build()
 	assertNoError(t, err)
 
 	if err := display.Init(); err != nil {
@@ -805,9 +954,34 @@ func TestDisplay_ShowImage_ExactSizeImage(t *testing.T) {
 	assertMethodCalled(t, mock, "Draw")
 }
 
+func TestDisplay_ShowImage_SubImage(t *testing.T) {
+	display, mock := newTestDisplay(t, newTestBuilder())
+
+	// An 8x8 white square at (10,10) in the parent image, with a decoy at
+	// the parent's origin that falls outside the sub-image.
+	parent := image.NewGray(image.Rect(0, 0, 40, 40))
+	for y := 10; y < 18; y++ {
+		for x := 10; x < 18; x++ {
+			parent.SetGray(x, y, color.Gray{Y: 255})
+		}
+	}
+	parent.SetGray(0, 0, color.Gray{Y: 255})
+	sub := parent.SubImage(image.Rect(10, 10, 40, 40))
+
+	assertNoError(t, display.ShowImage(sub))
+
+	_, src, _ := mock.LastDrawArgs()
+	if got := countOn(src, image.Rect(0, 0, 8, 8)); got != 64 {
+		t.Errorf("Expected the sub-image origin to map to the display origin, got %d of 64 pixels lit", got)
+	}
+	if got := countOn(src, src.Bounds()); got != 64 {
+		t.Errorf("Expected only the square to be drawn, got %d lit pixels", got)
+	}
+}
+
 func TestDisplay_ShowImage_WithoutInit(t *testing.T) {
 	mock := NewTrackedFakeSSD1306()
-	display, err := NewDisplay().WithBusName("/dev/i2c-0").WithDriver(mock).Build()
+	display, err := newTestBuilder().WithBusName("/dev/i2c-0").WithDriver(mock).Build()
 	assertNoError(t, err)
 
 	// Don't initialize the display
@@ -826,7 +1000,7 @@ func TestDisplay_ShowImage_DrawError(t *testing.T) {
 	mock := NewTrackedFakeSSD1306()
 	mock.ErrorOnDraw = true
 
-	display, err := NewDisplay().WithBusName("/dev/i2c-0").WithDriver(mock).Build()
+	display, err := newTestBuilder().WithBusName("/dev/i2c-0").WithDriver(mock).Build()
 	assertNoError(t, err)
 
 	if err := display.Init(); err != nil {
@@ -844,7 +1018,7 @@ func TestDisplay_ShowImageFromFile_Integration(t *testing.T) {
 	// This test just verifies that ShowImageFromFile calls ShowImage
 	// We can't easily test file operations without creating temporary files
 	mock := NewTrackedFakeSSD1306()
-	display, err := NewDisplay().WithBusName("/dev/i2c-0").WithDriver(mock).Build()
+	display, err := newTestBuilder().WithBusName("/dev/i2c-0").WithDriver(mock).Build()
 	assertNoError(t, err)
 
 	if err := display.Init(); err != nil {
@@ -863,7 +1037,7 @@ func TestDisplay_ShowImageFromFile_Integration(t *testing.T) {
 
 func TestDisplay_SetFont(t *testing.T) {
 	mock := NewTrackedFakeSSD1306()
-	display, err := NewDisplay().WithBusName("/dev/i2c-0").WithDriver(mock).Build()
+	display, err := newTestBuilder().WithBusName("/dev/i2c-0").WithDriver(mock).Build()
 	assertNoError(t, err)
 
 	// Initialize the display
@@ -873,7 +1047,7 @@ func TestDisplay_SetFont(t *testing.T) {
 
 	// Set a new font (using the same font for simplicity, but this demonstrates the method works)
 	newFont := basicfont.Face7x13
-	display.SetFont(newFont)
+	assertNoError(t, display.SetFont(newFont))
 
 	// Verify the font was changed
 	if display.font != newFont {
@@ -897,3 +1071,761 @@ func TestDisplay_SetFont(t *testing.T) {
 
 	assertMethodCalled(t, mock, "Draw")
 }
+
+// zeroHeightFace is a font face whose metrics report no line height, as
+// happens with a misconfigured size or DPI.
+type zeroHeightFace struct {
+	font.Face
+}
+
+func (zeroHeightFace) Metrics() font.Metrics {
+	return font.Metrics{}
+}
+
+func TestDisplay_FontValidation(t *testing.T) {
+	badFont := zeroHeightFace{basicfont.Face7x13}
+
+	t.Run("WithFont fails Build", func(t *testing.T) {
+		_, err := NewDisplay().WithFont(badFont).Build()
+		assertError(t, err, "invalid line height")
+	})
+
+	t.Run("SetFont returns error", func(t *testing.T) {
+		display, _ := newTestDisplay(t, newTestBuilder())
+
+		err := display.SetFont(badFont)
+		assertError(t, err, "invalid line height")
+
+		if display.font != basicfont.Face7x13 {
+			t.Error("Expected previous font to be kept")
+		}
+	})
+}
+
+func TestDisplay_WithViewport(t *testing.T) {
+	viewport := image.Rect(0, 0, 128, 16)
+	display, mock := newTestDisplay(t, newTestBuilder().WithViewport(viewport))
+
+	assertNoError(t, display.PrintLine(0, "gjpqy WXYZ gjpqy"))
+	assertError(t, display.PrintLine(1, "Too far"), "viewport only fits 1 lines")
+	assertError(t, display.PrintLines(0, []string{"One", "Two"}), "viewport only fits 1 lines")
+
+	below := image.Rect(0, 16, 128, 64)
+	assertNoError(t, display.Update())
+	_, src, _ := mock.LastDrawArgs()
+	if got := countOn(src, below); got != 0 {
+		t.Errorf("Expected pixels below the viewport to stay off, got %d lit", got)
+	}
+
+	// Content below the viewport should survive Update.
+	assertNoError(t, display.DrawText(image.Pt(0, 40), "Image"))
+	framebufferBelow := countOn(display.fb, below)
+
+	assertNoError(t, display.Update())
+
+	_, src, _ = mock.LastDrawArgs()
+	if countOn(src, viewport) == 0 {
+		t.Error("Expected text to be drawn inside the viewport")
+	}
+	if got := countOn(src, below); got != framebufferBelow {
+		t.Errorf("Expected %d pixels below the viewport, got %d", framebufferBelow, got)
+	}
+}
+
+func TestDisplay_WithViewport_OutOfBounds(t *testing.T) {
+	display, err := newTestBuilder().
+		WithDriver(NewTrackedFakeSSD1306()).
+		WithViewport(image.Rect(0, 48, 128, 80)).
+		Build()
+	assertNoError(t, err)
+
+	assertError(t, display.Init(), "outside of display bounds")
+}
+
+func TestDisplay_PrintAt(t *testing.T) {
+	tests := []struct {
+		name     string
+		initial  string
+		col      int
+		text     string
+		expected string
+	}{
+		{
+			name:     "replaces value portion",
+			initial:  "temp: 00",
+			col:      6,
+			text:     "42",
+			expected: "temp: 42",
+		},
+		{
+			name:     "keeps trailing text",
+			initial:  "temp: 00 C",
+			col:      6,
+			text:     "7",
+			expected: "temp: 70 C",
+		},
+		{
+			name:     "extends line",
+			initial:  "temp: 00",
+			col:      6,
+			text:     "100",
+			expected: "temp: 100",
+		},
+		{
+			name:     "pads short line",
+			initial:  "ab",
+			col:      4,
+			text:     "cd",
+			expected: "ab  cd",
+		},
+		{
+			name:     "counts runes not bytes",
+			initial:  "température",
+			col:      2,
+			text:     "X",
+			expected: "teXpérature",
+		},
+	}
+
+	display, _ := newTestDisplay(t, newTestBuilder())
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertNoError(t, display.PrintLine(0, tt.initial))
+			assertNoError(t, display.PrintAt(0, tt.col, tt.text))
+
+			if display.buffer[0] != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, display.buffer[0])
+			}
+		})
+	}
+
+	assertError(t, display.PrintAt(DEFAULT_MAX_LINES, 0, "x"), "display only has")
+	assertError(t, display.PrintAt(0, -1, "x"), "invalid column")
+}
+
+func TestDisplay_EraseToEndOfLine(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder())
+
+	assertNoError(t, display.PrintLine(0, "Temp: 21.5C"))
+	assertNoError(t, display.EraseToEndOfLine(0, 5))
+	if display.buffer[0] != "Temp:" {
+		t.Errorf("Expected %q, got %q", "Temp:", display.buffer[0])
+	}
+
+	assertNoError(t, display.EraseToEndOfLine(0, 20))
+	if display.buffer[0] != "Temp:" {
+		t.Errorf("Expected erasing past the end to leave %q, got %q", "Temp:", display.buffer[0])
+	}
+
+	assertError(t, display.EraseToEndOfLine(DEFAULT_MAX_LINES, 0), "display only has")
+	assertError(t, display.EraseToEndOfLine(0, -1), "invalid column")
+}
+
+func TestDisplay_WithTrimLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		builder  *Display
+		expected string
+	}{
+		{"disabled", newTestBuilder(), "  value \t"},
+		{"trailing", newTestBuilder().WithTrimLines(true), "  value"},
+		{"both", newTestBuilder().WithTrimLines(true).WithTrimLeadingSpace(true), "value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			display, _ := newTestDisplay(t, tt.builder)
+
+			assertNoError(t, display.PrintLine(0, "  value \t"))
+			assertNoError(t, display.PrintLines(1, []string{"  value \t"}))
+			for i := range 2 {
+				if display.buffer[i] != tt.expected {
+					t.Errorf("Line %d: expected %q, got %q", i, tt.expected, display.buffer[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDisplay_WithSanitizeUTF8(t *testing.T) {
+	invalid := "ok\xff\xfeok"
+
+	display, _ := newTestDisplay(t, newTestBuilder().WithSanitizeUTF8(true))
+	assertNoError(t, display.PrintLine(0, invalid))
+	assertNoError(t, display.PrintLines(1, []string{invalid}))
+	for i := range 2 {
+		if want := "ok\uFFFDok"; display.buffer[i] != want {
+			t.Errorf("Line %d: expected %q, got %q", i, want, display.buffer[i])
+		}
+	}
+
+	raw, _ := newTestDisplay(t, newTestBuilder())
+	assertNoError(t, raw.PrintLine(0, invalid))
+	if raw.buffer[0] != invalid {
+		t.Errorf("Expected invalid input to be stored as-is by default, got %q", raw.buffer[0])
+	}
+}
+
+func TestDisplay_ForceRefresh(t *testing.T) {
+	display, mock := newTestDisplay(t, newTestBuilder().WithDedup(true))
+
+	assertNoError(t, display.PrintLine(0, "Hello"))
+	assertNoError(t, display.Update())
+	assertNoError(t, display.Update())
+	if got := mock.CallCount("Draw"); got != 1 {
+		t.Fatalf("Expected the repeated Update to be skipped, got %d draws", got)
+	}
+
+	assertNoError(t, display.ForceRefresh())
+	if got := mock.CallCount("Draw"); got != 2 {
+		t.Errorf("Expected ForceRefresh to draw, got %d draws", got)
+	}
+	if display.Dirty() {
+		t.Error("Expected display to be clean after ForceRefresh")
+	}
+
+	blank, blankMock := newTestDisplay(t, newTestBuilder())
+	assertNoError(t, blank.ForceRefresh())
+	if got := blankMock.CallCount("Draw"); got != 1 {
+		t.Errorf("Expected ForceRefresh to draw a blank frame, got %d draws", got)
+	}
+}
+
+func TestDisplay_String(t *testing.T) {
+	if got := newTestBuilder().String(); got != "" {
+		t.Errorf("Expected empty string before Init, got %q", got)
+	}
+
+	display, _ := newTestDisplay(t, newTestBuilder().WithLines(3))
+	assertNoError(t, display.PrintLines(0, []string{"one", "two"}))
+	if got, want := fmt.Sprint(display), "one\ntwo\n"; got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestDisplay_WithClock(t *testing.T) {
+	fixed := time.Date(2024, time.March, 9, 12, 34, 56, 0, time.UTC)
+	display, mock := newTestDisplay(t, newTestBuilder().WithClock(func() time.Time { return fixed }))
+
+	assertNoError(t, display.PrintTime(0, "15:04:05"))
+	assertNoError(t, display.PrintTime(1, "2006-01-02"))
+
+	if display.buffer[0] != "12:34:56" {
+		t.Errorf("Expected %q, got %q", "12:34:56", display.buffer[0])
+	}
+	if display.buffer[1] != "2024-03-09" {
+		t.Errorf("Expected %q, got %q", "2024-03-09", display.buffer[1])
+	}
+
+	assertNoError(t, display.Update())
+	assertMethodCalled(t, mock, "Draw")
+	if !display.lastDraw.Equal(fixed) {
+		t.Errorf("Expected last draw time to come from the clock, got %v", display.lastDraw)
+	}
+}
+
+func TestDisplay_Resize(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder().WithLines(3))
+
+	assertNoError(t, display.PrintLines(0, []string{"one", "two", "three"}))
+
+	// Grow: existing content is preserved and new lines are blank.
+	assertNoError(t, display.Resize(5))
+	expected := []string{"one", "two", "three", "", ""}
+	if strings.Join(display.buffer, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected buffer %q after growing, got %q", expected, display.buffer)
+	}
+	assertNoError(t, display.PrintLine(4, "five"))
+
+	// Shrink: lines beyond the new count are dropped.
+	assertNoError(t, display.Resize(2))
+	expected = []string{"one", "two"}
+	if strings.Join(display.buffer, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected buffer %q after shrinking, got %q", expected, display.buffer)
+	}
+	assertError(t, display.PrintLine(2, "three"), "display only has 2 lines")
+
+	// More lines than fit on the panel are rejected.
+	assertError(t, display.Resize(6), "display only fits 5 lines")
+	if len(display.buffer) != 2 || display.lines != 2 {
+		t.Errorf("Expected failed resize to leave 2 lines, got %d", len(display.buffer))
+	}
+}
+
+func TestDisplay_PrintLinesExact(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder())
+	assertNoError(t, display.PrintLines(0, []string{"1", "2", "3", "4", "5"}))
+
+	assertNoError(t, display.PrintLinesExact(0, []string{"one", "two"}))
+	expected := []string{"one", "two", "", "", ""}
+	if strings.Join(display.buffer, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected buffer %q, got %q", expected, display.buffer)
+	}
+
+	assertError(t, display.PrintLinesExact(4, []string{"a", "b"}), "text requires more than 5 lines")
+}
+
+func TestDisplay_PrintParagraph(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder())
+
+	// The 7 pixel wide default font fits 18 characters on a 128 pixel line.
+	used, err := display.PrintParagraph(1, "The quick brown fox jumps over the lazy dog twice.")
+	assertNoError(t, err)
+	if used != 3 {
+		t.Errorf("Expected 3 lines to be used, got %d", used)
+	}
+	expected := []string{"", "The quick brown", "fox jumps over the", "lazy dog twice.", ""}
+	if strings.Join(display.buffer, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected buffer %q, got %q", expected, display.buffer)
+	}
+
+	_, err = display.PrintParagraph(3, "The quick brown fox jumps over the lazy dog twice.")
+	if !errors.Is(err, ErrTextTruncated) {
+		t.Errorf("Expected ErrTextTruncated, got %v", err)
+	}
+	if display.buffer[3] != "lazy dog twice." {
+		t.Errorf("Expected a paragraph that does not fit to leave the buffer alone, got %q", display.buffer[3])
+	}
+}
+
+func TestDisplay_PrintParagraph_NoWidth(t *testing.T) {
+	// A single digit gutter is two 7 pixel cells, which fills the viewport.
+	display, _ := newTestDisplay(t, newTestBuilder().WithLineNumbers(true).WithViewport(image.Rect(0, 0, 14, 64)))
+
+	_, err := display.PrintParagraph(0, "text")
+	assertError(t, err, "no room for text")
+	if display.buffer[0] != "" {
+		t.Errorf("Expected the buffer to be left alone, got %q", display.buffer[0])
+	}
+}
+
+func TestDisplay_Render(t *testing.T) {
+	display, mock := newTestDisplay(t, newTestBuilder().WithLines(3))
+	assertNoError(t, display.PrintLines(0, []string{"old", "old", "old"}))
+
+	assertNoError(t, display.Render([]string{"one", "two"}))
+	if got := mock.CallCount("Draw"); got != 1 {
+		t.Errorf("Expected exactly 1 Draw call, got %d", got)
+	}
+	expected := []string{"one", "two", ""}
+	if strings.Join(display.buffer, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected buffer %q, got %q", expected, display.buffer)
+	}
+
+	// Too many lines are rejected without touching the buffer.
+	assertError(t, display.Render([]string{"a", "b", "c", "d"}), "display only has 3 lines")
+	if strings.Join(display.buffer, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected failed Render to leave buffer %q, got %q", expected, display.buffer)
+	}
+}
+
+func TestDisplay_PrintLineScroll(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder().WithLines(3))
+
+	for _, text := range []string{"one", "two"} {
+		assertNoError(t, display.PrintLineScroll(text))
+	}
+	expected := []string{"one", "two", ""}
+	if strings.Join(display.buffer, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected buffer %q, got %q", expected, display.buffer)
+	}
+
+	_, err := display.Write([]byte("three\nfour\n"))
+	assertNoError(t, err)
+	expected = []string{"two", "three", "four"}
+	if strings.Join(display.buffer, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected buffer %q after scrolling, got %q", expected, display.buffer)
+	}
+}
+
+func TestDisplay_WithReverseScroll(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder().WithLines(4).WithReverseScroll(true))
+
+	for _, text := range []string{"one", "two", "three"} {
+		assertNoError(t, display.PrintLineScroll(text))
+	}
+	expected := []string{"", "one", "two", "three"}
+	if strings.Join(display.buffer, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected newest line at the bottom %q, got %q", expected, display.buffer)
+	}
+
+	_, err := display.Write([]byte("four\nfive"))
+	assertNoError(t, err)
+	expected = []string{"two", "three", "four", "five"}
+	if strings.Join(display.buffer, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected buffer %q, got %q", expected, display.buffer)
+	}
+}
+
+func TestDisplay_WithAutoUpdate(t *testing.T) {
+	display, mock := newTestDisplay(t, newTestBuilder().WithAutoUpdate(true))
+
+	assertNoError(t, display.PrintLine(0, "hello"))
+	if got := mock.CallCount("Draw"); got != 1 {
+		t.Fatalf("Expected PrintLine to draw once, got %d draws", got)
+	}
+
+	// Compound operations still produce a single draw.
+	assertNoError(t, display.Render([]string{"one", "two"}))
+	assertNoError(t, display.PrintLinesExact(0, []string{"three"}))
+	if got := mock.CallCount("Draw"); got != 3 {
+		t.Errorf("Expected one draw per call, got %d draws", got)
+	}
+
+	manual, mock := newTestDisplay(t, newTestBuilder())
+	assertNoError(t, manual.PrintLine(0, "hello"))
+	if mock.WasCalled("Draw") {
+		t.Error("Expected no draw without auto-update")
+	}
+}
+
+func TestDisplay_Dirty(t *testing.T) {
+	display, mock := newTestDisplay(t, newTestBuilder())
+	if display.Dirty() {
+		t.Error("Expected a new display not to be dirty")
+	}
+
+	assertNoError(t, display.PrintLine(0, "hello"))
+	if !display.Dirty() {
+		t.Error("Expected display to be dirty after PrintLine")
+	}
+	assertNoError(t, display.Update())
+	if display.Dirty() {
+		t.Error("Expected display not to be dirty after Update")
+	}
+
+	assertNoError(t, display.DrawLine(0, 0, 10, 0, true))
+	if !display.Dirty() {
+		t.Error("Expected display to be dirty after DrawLine")
+	}
+
+	// A failed update leaves the display dirty.
+	mock.ErrorOnDraw = true
+	assertError(t, display.Update(), "failed to draw on display")
+	if !display.Dirty() {
+		t.Error("Expected display to stay dirty after a failed Update")
+	}
+}
+
+func TestDisplay_WithLineNumbers(t *testing.T) {
+	renderLine := func(builder *Display) (*Display, *image1bit.VerticalLSB) {
+		display, mock := newTestDisplay(t, builder)
+		assertNoError(t, display.PrintLine(0, "HI"))
+		assertNoError(t, display.Update())
+		_, src, _ := mock.LastDrawArgs()
+		return display, src.(*image1bit.VerticalLSB)
+	}
+
+	_, plain := renderLine(newTestBuilder())
+	display, numbered := renderLine(newTestBuilder().WithLineNumbers(true))
+
+	// Five lines need one digit, plus one cell of padding.
+	gutter := display.gutterWidth()
+	if want := 2 * display.cellWidth(); gutter != want {
+		t.Fatalf("Expected gutter width %d, got %d", want, gutter)
+	}
+
+	band := display.lineRect(0)
+	textBand := image.Rect(gutter, band.Min.Y, band.Max.X, band.Max.Y)
+	want := litExtent(clippedImage{Image: plain, clip: band}).Add(image.Pt(gutter, 0))
+	if got := litExtent(clippedImage{Image: numbered, clip: textBand}); got != want {
+		t.Errorf("Expected text shifted right to %v, got %v", want, got)
+	}
+
+	for i := range 5 {
+		r := display.lineRect(i)
+		if countOn(numbered, image.Rect(0, r.Min.Y, gutter, r.Max.Y)) == 0 {
+			t.Errorf("Expected a line number in the gutter of line %d", i)
+		}
+	}
+}
+
+func TestDisplay_SetLineColors(t *testing.T) {
+	display, mock := newTestDisplay(t, newTestBuilder())
+
+	assertNoError(t, display.PrintLine(1, "BAR"))
+	assertNoError(t, display.SetLineBackground(1, true))
+	assertNoError(t, display.PrintLine(2, "HIDDEN"))
+	assertNoError(t, display.SetLineForeground(2, false))
+	assertNoError(t, display.Update())
+
+	_, src, _ := mock.LastDrawArgs()
+	img := src.(*image1bit.VerticalLSB)
+	bar := display.lineRect(1)
+	if got, want := countOn(img, bar), bar.Dx()*bar.Dy(); got != want {
+		t.Errorf("Expected line with bg and fg on to be all on (%d pixels), got %d", want, got)
+	}
+	if got := countOn(img, display.lineRect(2)); got != 0 {
+		t.Errorf("Expected line with fg off to be blank, got %d lit pixels", got)
+	}
+
+	assertError(t, display.SetLineBackground(10, true), "only has 5 lines")
+}
+
+func TestDisplay_LineColors_ResizeAndScroll(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder().WithLines(3))
+
+	// Colors of lines dropped by shrinking do not come back on growing.
+	assertNoError(t, display.SetLineBackground(2, true))
+	assertNoError(t, display.SetLineForeground(2, false))
+	assertNoError(t, display.Resize(2))
+	assertNoError(t, display.Resize(3))
+	if fg, bg := display.lineColors(2); fg != image1bit.On || bg != image1bit.Off {
+		t.Errorf("Expected line 2 to have default colors after resizing, got fg=%v bg=%v", fg, bg)
+	}
+
+	// Colors move up with the text when lines scroll.
+	for _, text := range []string{"one", "two", "three"} {
+		assertNoError(t, display.PrintLineScroll(text))
+	}
+	assertNoError(t, display.SetLineBackground(1, true))
+	assertNoError(t, display.PrintLineScroll("four"))
+	if display.buffer[0] != "two" {
+		t.Fatalf("Expected %q to scroll to line 0, got %q", "two", display.buffer[0])
+	}
+	if _, bg := display.lineColors(0); bg != image1bit.On {
+		t.Error("Expected the background of line 1 to scroll up to line 0")
+	}
+	if _, bg := display.lineColors(1); bg != image1bit.Off {
+		t.Error("Expected line 1 to take the default background of line 2")
+	}
+}
+
+func TestDisplay_PrintLineOffset(t *testing.T) {
+	renderLine := func(yOffset int) image.Rectangle {
+		display, mock := newTestDisplay(t, newTestBuilder())
+		assertNoError(t, display.PrintLineOffset(1, yOffset, "HI"))
+		assertNoError(t, display.Update())
+		_, src, _ := mock.LastDrawArgs()
+		return litExtent(src.(*image1bit.VerticalLSB))
+	}
+
+	plain := renderLine(0)
+	for _, yOffset := range []int{3, -2} {
+		if got := renderLine(yOffset); got.Min.Y != plain.Min.Y+yOffset {
+			t.Errorf("Expected offset %d to move top pixel from %d to %d, got %d", yOffset, plain.Min.Y, plain.Min.Y+yOffset, got.Min.Y)
+		}
+	}
+
+	display, _ := newTestDisplay(t, newTestBuilder())
+	assertNoError(t, display.PrintLineOffset(0, 4, "HI"))
+	assertNoError(t, display.PrintLine(0, "HI"))
+	if _, ok := display.lineOffsets[0]; ok {
+		t.Error("Expected PrintLine to reset the line offset")
+	}
+}
+
+func TestDisplay_WithScreenBorder(t *testing.T) {
+	renderLine := func(builder *Display) *image1bit.VerticalLSB {
+		display, mock := newTestDisplay(t, builder)
+		assertNoError(t, display.PrintLine(0, "HI"))
+		assertNoError(t, display.Update())
+		_, src, _ := mock.LastDrawArgs()
+		return src.(*image1bit.VerticalLSB)
+	}
+
+	plain := renderLine(newTestBuilder())
+	framed := renderLine(newTestBuilder().WithScreenBorder(true))
+
+	bounds := framed.Bounds()
+	inner := bounds.Inset(1)
+	if got, want := countOn(framed, bounds)-countOn(framed, inner), bounds.Dx()*bounds.Dy()-inner.Dx()*inner.Dy(); got != want {
+		t.Errorf("Expected all %d edge pixels to be on, got %d", want, got)
+	}
+
+	want := litExtent(plain).Add(image.Pt(1, 1))
+	if got := litExtent(clippedImage{Image: framed, clip: inner}); got != want {
+		t.Errorf("Expected text inset to %v, got %v", want, got)
+	}
+}
+
+func TestDisplay_WithInvertedBackground(t *testing.T) {
+	render := func(builder *Display) *image1bit.VerticalLSB {
+		display, mock := newTestDisplay(t, builder)
+		assertNoError(t, display.PrintLine(0, "HI"))
+		assertNoError(t, display.FillRect(image.Rect(100, 50, 110, 60), true))
+		assertNoError(t, display.Update())
+		_, src, _ := mock.LastDrawArgs()
+		return src.(*image1bit.VerticalLSB)
+	}
+
+	plain := render(newTestBuilder())
+	inverted := render(newTestBuilder().WithInvertedBackground(true))
+
+	for i := range plain.Pix {
+		if inverted.Pix[i] != ^plain.Pix[i] {
+			t.Fatalf("Expected the inverted frame to be the exact negative of the plain one, differs at byte %d", i)
+		}
+	}
+
+	bounds := inverted.Bounds()
+	lit := countOn(inverted, bounds)
+	if dark := bounds.Dx()*bounds.Dy() - lit; dark == 0 || lit < dark {
+		t.Errorf("Expected a lit background with dark glyphs, got %d lit and %d dark pixels", lit, dark)
+	}
+	if got := countOn(inverted, image.Rect(100, 50, 110, 60)); got != 0 {
+		t.Errorf("Expected framebuffer drawing to show dark, got %d lit pixels", got)
+	}
+}
+
+func TestDisplay_Update_SkipsBlankFrame(t *testing.T) {
+	display, mock := newTestDisplay(t, newTestBuilder())
+
+	assertNoError(t, display.PrintLine(0, "   "))
+	assertNoError(t, display.Update())
+	if mock.WasCalled("Draw") {
+		t.Fatal("Expected no draw for an empty buffer on a blank panel")
+	}
+
+	// Once something is shown, clearing it must still be drawn.
+	assertNoError(t, display.PrintLine(0, "hello"))
+	assertNoError(t, display.Update())
+	assertNoError(t, display.ClearLines())
+	assertNoError(t, display.Update())
+	if got := mock.CallCount("Draw"); got != 2 {
+		t.Errorf("Expected the cleared frame to be drawn, got %d draws", got)
+	}
+
+	// The panel is blank again, so another empty update is skipped.
+	assertNoError(t, display.Update())
+	if got := mock.CallCount("Draw"); got != 2 {
+		t.Errorf("Expected no further draws, got %d", got)
+	}
+
+	always, mock := newTestDisplay(t, newTestBuilder().WithAlwaysDraw(true))
+	assertNoError(t, always.Update())
+	if got := mock.CallCount("Draw"); got != 1 {
+		t.Errorf("Expected a draw with WithAlwaysDraw, got %d", got)
+	}
+}
+
+func TestDisplay_WithDedup(t *testing.T) {
+	display, mock := newTestDisplay(t, newTestBuilder().WithDedup(true))
+
+	assertNoError(t, display.PrintLine(0, "static"))
+	assertNoError(t, display.Update())
+	assertNoError(t, display.Update())
+	if got := mock.CallCount("Draw"); got != 1 {
+		t.Errorf("Expected identical frames to be drawn once, got %d draws", got)
+	}
+
+	assertNoError(t, display.PrintLine(0, "changed"))
+	assertNoError(t, display.Update())
+	if got := mock.CallCount("Draw"); got != 2 {
+		t.Errorf("Expected a changed frame to be drawn, got %d draws", got)
+	}
+
+	img := newFilledImage(16, 16, 255)
+	assertNoError(t, display.ShowImage(img))
+	assertNoError(t, display.ShowImage(img))
+	if got := mock.CallCount("Draw"); got != 3 {
+		t.Errorf("Expected a repeated image to be drawn once, got %d draws", got)
+	}
+
+	plain, mock := newTestDisplay(t, newTestBuilder())
+	assertNoError(t, plain.PrintLine(0, "static"))
+	assertNoError(t, plain.Update())
+	assertNoError(t, plain.Update())
+	if got := mock.CallCount("Draw"); got != 2 {
+		t.Errorf("Expected every update to draw without dedup, got %d draws", got)
+	}
+}
+
+func TestDisplay_Append(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder().WithAutoScrollScreen(true))
+
+	for i := range 20 {
+		assertNoError(t, display.Append(fmt.Sprintf("line %d", i+1)))
+	}
+	expected := []string{"line 16", "line 17", "line 18", "line 19", "line 20"}
+	if strings.Join(display.buffer, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected the last 5 lines %q, got %q", expected, display.buffer)
+	}
+	if len(display.history) != 20 {
+		t.Errorf("Expected all 20 lines to be kept, got %d", len(display.history))
+	}
+
+	fixed, _ := newTestDisplay(t, newTestBuilder())
+	assertNoError(t, fixed.Append("one", "two", "three"))
+	assertNoError(t, fixed.Append("four", "five"))
+	assertError(t, fixed.Append("six"), "text requires 6 lines but display only has 5 lines")
+	expected = []string{"one", "two", "three", "four", "five"}
+	if strings.Join(fixed.buffer, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected buffer %q, got %q", expected, fixed.buffer)
+	}
+}
+
+func TestDisplay_WithHeaderLines(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder().WithHeaderLines(1).WithAutoScrollScreen(true))
+
+	assertNoError(t, display.PrintLine(0, "TITLE"))
+	for i := range 20 {
+		assertNoError(t, display.Append(fmt.Sprintf("line %d", i+1)))
+	}
+	expected := []string{"TITLE", "line 17", "line 18", "line 19", "line 20"}
+	if strings.Join(display.buffer, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected fixed header over the last 4 lines %q, got %q", expected, display.buffer)
+	}
+
+	scroll, _ := newTestDisplay(t, newTestBuilder().WithHeaderLines(1))
+	assertNoError(t, scroll.PrintLine(0, "TITLE"))
+	for i := range 6 {
+		assertNoError(t, scroll.PrintLineScroll(fmt.Sprintf("line %d", i+1)))
+	}
+	expected = []string{"TITLE", "line 3", "line 4", "line 5", "line 6"}
+	if strings.Join(scroll.buffer, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected fixed header over scrolled lines %q, got %q", expected, scroll.buffer)
+	}
+}
+
+// sizedSSD1306 is a tracked fake that reports different bounds.
+type sizedSSD1306 struct {
+	*TrackedFakeSSD1306
+	bounds image.Rectangle
+}
+
+func (s *sizedSSD1306) Bounds() image.Rectangle {
+	return s.bounds
+}
+
+// blockingSSD1306 is a tracked fake whose Draw blocks until released while
+// blocking is enabled.
+type blockingSSD1306 struct {
+	*TrackedFakeSSD1306
+	blocking atomic.Bool
+	release  chan struct{}
+}
+
+func (b *blockingSSD1306) Draw(r image.Rectangle, src image.Image, sp image.Point) error {
+	if b.blocking.Load() {
+		<-b.release
+	}
+	return b.TrackedFakeSSD1306.Draw(r, src, sp)
+}
+
+func TestDisplay_WithDrawTimeout(t *testing.T) {
+	driver := &blockingSSD1306{
+		TrackedFakeSSD1306: NewTrackedFakeSSD1306(),
+		release:            make(chan struct{}),
+	}
+	defer close(driver.release)
+
+	display, err := newTestBuilder().WithDriver(driver).WithDrawTimeout(20 * time.Millisecond).Build()
+	assertNoError(t, err)
+	assertNoError(t, display.Init())
+	assertNoError(t, display.PrintLine(0, "hello"))
+
+	driver.blocking.Store(true)
+	err = display.Update()
+	if !errors.Is(err, ErrDrawTimeout) {
+		t.Fatalf("Expected ErrDrawTimeout from Update, got %v", err)
+	}
+	if !errors.Is(display.ShowImage(NewTestImage(8, 8)), ErrDrawTimeout) {
+		t.Fatal("Expected ErrDrawTimeout from ShowImage")
+	}
+
+	driver.blocking.Store(false)
+	assertNoError(t, display.Update())
+	assertMethodCalled(t, driver.TrackedFakeSSD1306, "Draw")
+}
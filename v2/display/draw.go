@@ -0,0 +1,733 @@
+package display
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+	"periph.io/x/devices/v3/ssd1306/image1bit"
+)
+
+var (
+	ErrTextTruncated = errors.New("text does not fit in the available area")
+)
+
+type (
+	// clippedImage restricts drawing operations to a sub-rectangle of an
+	// image.
+	clippedImage struct {
+		draw.Image
+		clip image.Rectangle
+	}
+
+	// HAlign is the horizontal alignment of text within a rectangle.
+	HAlign int
+
+	// VAlign is the vertical alignment of text within a rectangle.
+	VAlign int
+
+	// KV is a label and value pair for DrawKV.
+	KV struct {
+		Key   string
+		Value string
+	}
+)
+
+const (
+	AlignLeft HAlign = iota
+	AlignCenter
+	AlignRight
+)
+
+const (
+	AlignTop VAlign = iota
+	AlignMiddle
+	AlignBottom
+)
+
+func (c clippedImage) Bounds() image.Rectangle {
+	return c.clip.Intersect(c.Image.Bounds())
+}
+
+// DrawText draws a single line of text into the framebuffer with the top
+// left corner of the line at the given point. The result is visible after
+// the next call to Update.
+func (d *Display) DrawText(at image.Point, text string) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	screen := font.Drawer{
+		Dst:  clippedImage{Image: d.fb, clip: d.clipRect()},
+		Src:  &image.Uniform{image1bit.On},
+		Face: d.font,
+		Dot:  fixed.P(at.X, at.Y+d.baseline(0)),
+	}
+	d.drawString(&screen, text)
+	return d.changed()
+}
+
+// DrawTextSnapped draws text into the framebuffer on the baseline of the
+// given text line, exactly where PrintLine would place it, so that text
+// drawn with the pixel API lines up with buffered text.
+func (d *Display) DrawTextSnapped(line uint, text string) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	if int(line) >= len(d.buffer) {
+		return fmt.Errorf("request to draw on line %d but display only has %d lines", line, len(d.buffer))
+	}
+
+	area := d.textArea()
+	return d.DrawText(image.Pt(area.Min.X+d.gutterWidth(), area.Min.Y+d.lineHeight*int(line)), text)
+}
+
+// DrawTextInRect draws a single line of text into the framebuffer, aligned
+// within r according to h and v and clipped to r. Vertical alignment uses the
+// ascent and descent of the active font rather than the extent of the
+// particular glyphs drawn.
+func (d *Display) DrawTextInRect(r image.Rectangle, text string, h HAlign, v VAlign) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	r = r.Canon()
+	metrics := d.font.Metrics()
+	ascent, descent := metrics.Ascent.Ceil(), metrics.Descent.Ceil()
+
+	x := r.Min.X
+	switch width := d.textWidth(text); h {
+	case AlignCenter:
+		x += (r.Dx() - width) / 2
+	case AlignRight:
+		x = r.Max.X - width
+	}
+
+	y := r.Min.Y + ascent
+	switch v {
+	case AlignMiddle:
+		y += (r.Dy() - ascent - descent) / 2
+	case AlignBottom:
+		y = r.Max.Y - descent
+	}
+
+	screen := font.Drawer{
+		Dst:  clippedImage{Image: d.fb, clip: r.Intersect(d.clipRect())},
+		Src:  &image.Uniform{image1bit.On},
+		Face: d.font,
+		Dot:  fixed.P(x, y),
+	}
+	d.drawString(&screen, text)
+	return d.changed()
+}
+
+// TextBounds returns the tight bounding box of the pixels text would cover
+// when drawn in the active font, relative to a dot at the origin on the
+// baseline. Ascenders extend to negative y and descenders to positive y.
+func (d *Display) TextBounds(text string) image.Rectangle {
+	b, _ := font.BoundString(d.font, text)
+	r := image.Rect(b.Min.X.Floor(), b.Min.Y.Floor(), b.Max.X.Ceil(), b.Max.Y.Ceil())
+	if n := utf8.RuneCountInString(text); n > 1 {
+		r.Max.X += d.spacing * (n - 1)
+	}
+	return r
+}
+
+// textWidth returns the width in pixels of text in the active font,
+// including letter spacing.
+func (d *Display) textWidth(text string) int {
+	return font.MeasureString(d.font, text).Ceil() + d.spacing*utf8.RuneCountInString(text)
+}
+
+// drawString draws text with the configured letter spacing and, if enabled,
+// a one pixel outline in the opposite color.
+func (d *Display) drawString(screen *font.Drawer, text string) {
+	if d.textOutline {
+		src, dot := screen.Src, screen.Dot
+		screen.Src = &image.Uniform{image1bit.Bit(d.invertedBackground)}
+		for _, dx := range []int{-1, 0, 1} {
+			for _, dy := range []int{-1, 0, 1} {
+				if dx != 0 || dy != 0 {
+					screen.Dot = dot.Add(fixed.P(dx, dy))
+					d.drawGlyphs(screen, text)
+				}
+			}
+		}
+		screen.Src, screen.Dot = src, dot
+	}
+
+	d.drawGlyphs(screen, text)
+}
+
+// drawGlyphs draws text with the configured letter spacing.
+func (d *Display) drawGlyphs(screen *font.Drawer, text string) {
+	if d.spacing == 0 {
+		screen.DrawString(text)
+		return
+	}
+
+	prev := rune(-1)
+	for _, r := range text {
+		if prev >= 0 {
+			screen.Dot.X += screen.Face.Kern(prev, r)
+		}
+		start := screen.Dot.X
+		screen.DrawString(string(r))
+		advance := screen.Dot.X - start + fixed.I(d.spacing)
+		screen.Dot.X = start + max(advance, fixed.I(1))
+		prev = r
+	}
+}
+
+// DrawTextWrapped word-wraps text to the width of area and draws as many
+// lines as fit in its height into the framebuffer. It returns the number of
+// lines drawn; if some of the text did not fit, the returned error wraps
+// ErrTextTruncated.
+func (d *Display) DrawTextWrapped(area image.Rectangle, text string) (int, error) {
+	if !d.initialized {
+		return 0, fmt.Errorf("driver has not been initialized")
+	}
+
+	lines := wrapText(d.font, text, area.Dx(), d.spacing)
+	maxLines := d.linesInHeight(area.Dy())
+
+	screen := font.Drawer{
+		Dst:  clippedImage{Image: d.fb, clip: area.Intersect(d.clipRect())},
+		Src:  &image.Uniform{image1bit.On},
+		Face: d.font,
+	}
+
+	linesUsed := min(len(lines), maxLines)
+	for i := range linesUsed {
+		screen.Dot = fixed.P(area.Min.X, area.Min.Y+d.baseline(i))
+		d.drawString(&screen, lines[i])
+	}
+
+	if err := d.changed(); err != nil {
+		return linesUsed, err
+	}
+
+	if linesUsed < len(lines) {
+		return linesUsed, fmt.Errorf("%w: drew %d of %d lines", ErrTextTruncated, linesUsed, len(lines))
+	}
+
+	return linesUsed, nil
+}
+
+// DrawKV draws one key/value pair per text line into the framebuffer, with
+// the key aligned to the left of the text area and the value to the right.
+// Keys that would run into their value are truncated. If there are more pairs
+// than lines, the extra pairs are not drawn and the returned error wraps
+// ErrTextTruncated.
+func (d *Display) DrawKV(pairs []KV) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	area := d.textArea()
+	maxLines := min(len(d.buffer), d.linesInHeight(area.Dy()))
+	screen := font.Drawer{
+		Dst:  clippedImage{Image: d.fb, clip: area.Intersect(d.clipRect())},
+		Src:  &image.Uniform{image1bit.On},
+		Face: d.font,
+	}
+
+	linesUsed := min(len(pairs), maxLines)
+	for i, pair := range pairs[:linesUsed] {
+		y := area.Min.Y + d.baseline(i)
+		valueWidth := d.textWidth(pair.Value)
+		screen.Dot = fixed.P(area.Max.X-valueWidth, y)
+		d.drawString(&screen, pair.Value)
+
+		key := []rune(pair.Key)
+		keyWidth := area.Dx() - valueWidth - d.cellWidth()
+		for len(key) > 0 && d.textWidth(string(key)) > keyWidth {
+			key = key[:len(key)-1]
+		}
+		screen.Dot = fixed.P(area.Min.X, y)
+		d.drawString(&screen, string(key))
+	}
+
+	if err := d.changed(); err != nil {
+		return err
+	}
+
+	if linesUsed < len(pairs) {
+		return fmt.Errorf("%w: drew %d of %d pairs", ErrTextTruncated, linesUsed, len(pairs))
+	}
+
+	return nil
+}
+
+// cellWidth returns the width in pixels of a character cell in the active
+// font.
+func (d *Display) cellWidth() int {
+	advance, ok := d.font.GlyphAdvance('M')
+	if !ok {
+		return 0
+	}
+	return advance.Ceil()
+}
+
+// gutterWidth returns the width in pixels of the line number gutter: enough
+// for the largest line number plus one character cell of padding. It is 0
+// when line numbers are disabled.
+func (d *Display) gutterWidth() int {
+	if !d.lineNumbers {
+		return 0
+	}
+	digits := len(strconv.Itoa(len(d.buffer)))
+	return (digits + 1) * d.cellWidth()
+}
+
+// lineRect returns the band of the text area occupied by the given line.
+func (d *Display) lineRect(line int) image.Rectangle {
+	area := d.textArea()
+	top := area.Min.Y + d.baseline(line) + d.font.Metrics().Descent.Round() - d.lineHeight
+	return image.Rect(area.Min.X, top, area.Max.X, top+d.lineHeight).Intersect(area)
+}
+
+// RectPct returns a rectangle given as fractions of the display bounds, so
+// that RectPct(0, 0, 1, 0.5) is the top half of the display whatever its
+// size. Edges are rounded to the nearest pixel. The driver must be open.
+func (d *Display) RectPct(x, y, w, h float64) image.Rectangle {
+	bounds := d.driver.Bounds()
+	px := func(origin, size int, f float64) int {
+		return origin + int(math.Round(f*float64(size)))
+	}
+	return image.Rect(
+		px(bounds.Min.X, bounds.Dx(), x),
+		px(bounds.Min.Y, bounds.Dy(), y),
+		px(bounds.Min.X, bounds.Dx(), x+w),
+		px(bounds.Min.Y, bounds.Dy(), y+h),
+	)
+}
+
+// fillRect sets every pixel of img within r to the given value.
+func fillRect(img *image1bit.VerticalLSB, r image.Rectangle, b image1bit.Bit) {
+	r = r.Intersect(img.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetBit(x, y, b)
+		}
+	}
+}
+
+// strokeRect sets the one pixel outline of r within img.
+func strokeRect(img *image1bit.VerticalLSB, r image.Rectangle, b image1bit.Bit) {
+	fillRect(img, image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+1), b)
+	fillRect(img, image.Rect(r.Min.X, r.Max.Y-1, r.Max.X, r.Max.Y), b)
+	fillRect(img, image.Rect(r.Min.X, r.Min.Y, r.Min.X+1, r.Max.Y), b)
+	fillRect(img, image.Rect(r.Max.X-1, r.Min.Y, r.Max.X, r.Max.Y), b)
+}
+
+// xorRect flips every pixel of img within r.
+func xorRect(img *image1bit.VerticalLSB, r image.Rectangle) {
+	r = r.Intersect(img.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			img.SetBit(x, y, !img.BitAt(x, y))
+		}
+	}
+}
+
+// linesInHeight returns the number of text lines whose baseline fits within
+// the given pixel height.
+func (d *Display) linesInHeight(height int) int {
+	if d.lineHeight <= 0 {
+		return 0
+	}
+	first := d.baseline(0)
+	if height < first {
+		return 0
+	}
+	return (height-first)/d.lineHeight + 1
+}
+
+// wrapText breaks text into lines no wider than width pixels when rendered
+// with the given face and spacing pixels of letter spacing. Explicit
+// newlines are preserved. Words that are wider
+// than width on their own are split between characters, one per line if
+// width is not positive.
+func wrapText(face font.Face, text string, width, spacing int) []string {
+	width = max(width, 0)
+	fits := func(s string) bool {
+		return font.MeasureString(face, s).Ceil()+spacing*utf8.RuneCountInString(s) <= width
+	}
+
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		current := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if current != "" {
+				candidate = current + " " + word
+			}
+			if fits(candidate) {
+				current = candidate
+				continue
+			}
+
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+
+			// Split words that cannot fit on a line by themselves.
+			for word != "" && !fits(word) {
+				runes := []rune(word)
+				n := 1
+				for n < len(runes) && fits(string(runes[:n+1])) {
+					n++
+				}
+				lines = append(lines, string(runes[:n]))
+				word = string(runes[n:])
+			}
+			current = word
+		}
+		lines = append(lines, current)
+	}
+
+	return lines
+}
+
+// setPixel sets a single framebuffer pixel. Pixels outside of the display
+// or the clip region are ignored.
+func (d *Display) setPixel(x, y int, b image1bit.Bit) {
+	if d.clipping && !image.Pt(x, y).In(d.clip) {
+		return
+	}
+	d.fb.SetBit(x, y, b)
+}
+
+// fillFramebuffer sets every framebuffer pixel within r and the clip region.
+func (d *Display) fillFramebuffer(r image.Rectangle, b image1bit.Bit) {
+	fillRect(d.fb, r.Intersect(d.clipRect()), b)
+}
+
+// clipRect returns the region of the framebuffer that may be drawn on.
+func (d *Display) clipRect() image.Rectangle {
+	if d.clipping {
+		return d.clip.Intersect(d.fb.Bounds())
+	}
+	return d.fb.Bounds()
+}
+
+// SetClip restricts all drawing into the framebuffer, by primitives, images
+// and DrawText alike, to r until ClearClip is called. Text lines rendered by
+// Update are not affected.
+func (d *Display) SetClip(r image.Rectangle) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	d.clip = r.Canon()
+	d.clipping = true
+	return nil
+}
+
+// ClearClip removes the clip region set with SetClip.
+func (d *Display) ClearClip() {
+	d.clip = image.Rectangle{}
+	d.clipping = false
+}
+
+// bayer4 is a 4x4 ordered dither matrix.
+var bayer4 = [4][4]int{
+	{0, 8, 2, 10},
+	{12, 4, 14, 6},
+	{3, 11, 1, 9},
+	{15, 7, 13, 5},
+}
+
+// StippleRect fills r with an ordered dither pattern lighting density
+// percent of its pixels, which gives a shade of gray on a 1-bit panel.
+// Density 0 clears r and 100 lights all of it.
+func (d *Display) StippleRect(r image.Rectangle, density int) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	if density < 0 || density > 100 {
+		return fmt.Errorf("invalid density %d%%: must be between 0 and 100", density)
+	}
+
+	r = r.Canon().Intersect(d.fb.Bounds())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			d.setPixel(x, y, image1bit.Bit(bayer4[y%4][x%4]*100 < density*16))
+		}
+	}
+	return d.changed()
+}
+
+// CopyRect copies the part of the framebuffer within src so that its top
+// left corner is at dst. Overlapping source and destination regions are
+// handled correctly. Pixels of src that are not overwritten keep their
+// content, so scrolling a band also needs the vacated strip cleared, for
+// instance with FillRect.
+func (d *Display) CopyRect(src image.Rectangle, dst image.Point) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	src = src.Canon()
+	offset := dst.Sub(src.Min)
+	src = src.Intersect(d.fb.Bounds())
+	saved := image1bit.NewVerticalLSB(src)
+	for y := src.Min.Y; y < src.Max.Y; y++ {
+		for x := src.Min.X; x < src.Max.X; x++ {
+			saved.SetBit(x, y, d.fb.BitAt(x, y))
+		}
+	}
+
+	for y := src.Min.Y; y < src.Max.Y; y++ {
+		for x := src.Min.X; x < src.Max.X; x++ {
+			d.setPixel(x+offset.X, y+offset.Y, saved.BitAt(x, y))
+		}
+	}
+	return d.changed()
+}
+
+// FillRect sets every framebuffer pixel within r.
+func (d *Display) FillRect(r image.Rectangle, on bool) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	d.fillFramebuffer(r, image1bit.Bit(on))
+	return d.changed()
+}
+
+// DrawRoundedRect draws the outline of r with its corners rounded to quarter
+// circles of the given radius, or the whole shape if fill is set. The radius
+// is limited to half the shorter side of r.
+func (d *Display) DrawRoundedRect(r image.Rectangle, radius int, on bool, fill bool) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	if radius < 0 {
+		return fmt.Errorf("invalid radius %d", radius)
+	}
+
+	r = r.Canon()
+	if r.Empty() {
+		return d.changed()
+	}
+	radius = min(radius, (min(r.Dx(), r.Dy())-1)/2)
+	b := image1bit.Bit(on)
+
+	// Centers of the corner circles.
+	left, right := r.Min.X+radius, r.Max.X-1-radius
+	top, bottom := r.Min.Y+radius, r.Max.Y-1-radius
+
+	if fill {
+		d.fillFramebuffer(image.Rect(r.Min.X, top, r.Max.X, bottom+1), b)
+	} else {
+		d.drawLine(left, r.Min.Y, right, r.Min.Y, b)
+		d.drawLine(left, r.Max.Y-1, right, r.Max.Y-1, b)
+		d.drawLine(r.Min.X, top, r.Min.X, bottom, b)
+		d.drawLine(r.Max.X-1, top, r.Max.X-1, bottom, b)
+	}
+
+	x, y := radius, 0
+	e := 1 - radius
+	for x >= y {
+		for _, p := range [][2]int{{x, y}, {y, x}} {
+			dx, dy := p[0], p[1]
+			if fill {
+				d.drawLine(left-dx, top-dy, right+dx, top-dy, b)
+				d.drawLine(left-dx, bottom+dy, right+dx, bottom+dy, b)
+				continue
+			}
+			d.setPixel(left-dx, top-dy, b)
+			d.setPixel(right+dx, top-dy, b)
+			d.setPixel(left-dx, bottom+dy, b)
+			d.setPixel(right+dx, bottom+dy, b)
+		}
+		y++
+		if e < 0 {
+			e += 2*y + 1
+		} else {
+			x--
+			e += 2*(y-x) + 1
+		}
+	}
+	return d.changed()
+}
+
+// InvertRegion flips every framebuffer pixel within r, for highlighting a
+// selection over existing content. Inverting the same region again restores
+// it.
+func (d *Display) InvertRegion(r image.Rectangle) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	xorRect(d.fb, r.Canon().Intersect(d.clipRect()))
+	return d.changed()
+}
+
+// DrawLine draws a straight line between two points into the framebuffer.
+func (d *Display) DrawLine(x0, y0, x1, y1 int, on bool) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	d.drawLine(x0, y0, x1, y1, image1bit.Bit(on))
+	return d.changed()
+}
+
+// DrawGrid draws vertical and horizontal lines every spacing pixels across
+// the display into the framebuffer, starting at the top left corner.
+func (d *Display) DrawGrid(spacing int, on bool) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	if spacing <= 0 {
+		return fmt.Errorf("invalid grid spacing %d", spacing)
+	}
+
+	bounds := d.driver.Bounds()
+	for x := bounds.Min.X; x < bounds.Max.X; x += spacing {
+		d.drawLine(x, bounds.Min.Y, x, bounds.Max.Y-1, image1bit.Bit(on))
+	}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += spacing {
+		d.drawLine(bounds.Min.X, y, bounds.Max.X-1, y, image1bit.Bit(on))
+	}
+	return d.changed()
+}
+
+// drawLine draws a line using Bresenham's algorithm.
+func (d *Display) drawLine(x0, y0, x1, y1 int, b image1bit.Bit) {
+	dx := abs(x1 - x0)
+	dy := -abs(y1 - y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+
+	e := dx + dy
+	for {
+		d.setPixel(x0, y0, b)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+// DrawArc draws the part of a circle between two angles into the
+// framebuffer. Angles are in degrees, measured clockwise from 3 o'clock, and
+// the arc runs clockwise from startDeg to endDeg, so 350 to 10 describes a
+// 20 degree arc through 3 o'clock.
+func (d *Display) DrawArc(cx, cy, radius int, startDeg, endDeg float64, on bool) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	if radius < 0 {
+		return fmt.Errorf("invalid radius %d", radius)
+	}
+
+	d.arc(cx, cy, radius, startDeg, endDeg, image1bit.Bit(on))
+	return d.changed()
+}
+
+// DrawSector draws an arc as DrawArc does, along with the two radii joining
+// its ends to the center.
+func (d *Display) DrawSector(cx, cy, radius int, startDeg, endDeg float64, on bool) error {
+	return d.batch(func() error {
+		if err := d.DrawArc(cx, cy, radius, startDeg, endDeg, on); err != nil {
+			return err
+		}
+
+		for _, deg := range []float64{startDeg, endDeg} {
+			rad := deg * math.Pi / 180
+			x := cx + int(math.Round(float64(radius)*math.Cos(rad)))
+			y := cy + int(math.Round(float64(radius)*math.Sin(rad)))
+			d.drawLine(cx, cy, x, y, image1bit.Bit(on))
+		}
+
+		return nil
+	})
+}
+
+// arc draws the points of a midpoint circle whose angle falls within the
+// given range.
+func (d *Display) arc(cx, cy, radius int, startDeg, endDeg float64, b image1bit.Bit) {
+	plot := func(dx, dy int) {
+		if inArc(angleOf(dx, dy), startDeg, endDeg) {
+			d.setPixel(cx+dx, cy+dy, b)
+		}
+	}
+
+	x, y := radius, 0
+	e := 1 - radius
+	for x >= y {
+		for _, p := range [][2]int{{x, y}, {y, x}, {-y, x}, {-x, y}, {-x, -y}, {-y, -x}, {y, -x}, {x, -y}} {
+			plot(p[0], p[1])
+		}
+		y++
+		if e < 0 {
+			e += 2*y + 1
+		} else {
+			x--
+			e += 2*(y-x) + 1
+		}
+	}
+}
+
+// angleOf returns the angle in degrees of the offset (dx, dy), measured
+// clockwise from 3 o'clock, in the range [0, 360).
+func angleOf(dx, dy int) float64 {
+	return normalizeDegrees(math.Atan2(float64(dy), float64(dx)) * 180 / math.Pi)
+}
+
+// inArc reports whether angle lies on the clockwise arc from start to end.
+func inArc(angle, start, end float64) bool {
+	if end-start >= 360 || start-end >= 360 {
+		return true
+	}
+	span := normalizeDegrees(end - start)
+	if span == 0 && end != start {
+		return true
+	}
+	return normalizeDegrees(angle-start) <= span
+}
+
+func normalizeDegrees(deg float64) float64 {
+	deg = math.Mod(deg, 360)
+	if deg < 0 {
+		deg += 360
+	}
+	return deg
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
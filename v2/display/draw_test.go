@@ -0,0 +1,692 @@
+package display
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"testing"
+	"time"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"periph.io/x/devices/v3/ssd1306/image1bit"
+)
+
+func TestWrapText(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		width int
+		want  []string
+	}{
+		{
+			name:  "wraps on word boundaries",
+			text:  "the quick brown fox jumps over the lazy dog",
+			width: 70,
+			want:  []string{"the quick", "brown fox", "jumps over", "the lazy", "dog"},
+		},
+		{
+			name:  "preserves explicit newlines",
+			text:  "one\ntwo three",
+			width: 128,
+			want:  []string{"one", "two three"},
+		},
+		{
+			name:  "splits long words",
+			text:  "abcdefghijkl",
+			width: 35,
+			want:  []string{"abcde", "fghij", "kl"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := wrapText(basicfont.Face7x13, tt.text, tt.width, 0)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Expected %d lines, got %d: %q", len(tt.want), len(got), got)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Expected line %d to be %q, got %q", i, tt.want[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDisplay_DrawTextWrapped(t *testing.T) {
+	const paragraph = "the quick brown fox jumps over the lazy dog"
+
+	t.Run("fits", func(t *testing.T) {
+		display, _ := newTestDisplay(t, newTestBuilder())
+
+		area := image.Rect(0, 0, 128, 64)
+		linesUsed, err := display.DrawTextWrapped(area, paragraph)
+		assertNoError(t, err)
+
+		// 18 characters per line: "the quick brown", "fox jumps over the", "lazy dog"
+		if linesUsed != 3 {
+			t.Errorf("Expected 3 lines, got %d", linesUsed)
+		}
+		if countOn(display.fb, area) == 0 {
+			t.Error("Expected text to be drawn into the framebuffer")
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		display, _ := newTestDisplay(t, newTestBuilder())
+
+		area := image.Rect(10, 0, 80, 40)
+		linesUsed, err := display.DrawTextWrapped(area, paragraph)
+		if !errors.Is(err, ErrTextTruncated) {
+			t.Errorf("Expected ErrTextTruncated, got %v", err)
+		}
+
+		if linesUsed != 3 {
+			t.Errorf("Expected 3 lines, got %d", linesUsed)
+		}
+
+		bounds := display.fb.Bounds()
+		outside := countOn(display.fb, image.Rect(0, 0, area.Min.X, bounds.Max.Y)) +
+			countOn(display.fb, image.Rect(area.Max.X, 0, bounds.Max.X, bounds.Max.Y)) +
+			countOn(display.fb, image.Rect(0, area.Max.Y, bounds.Max.X, bounds.Max.Y))
+		if outside != 0 {
+			t.Errorf("Expected no pixels outside of area, found %d", outside)
+		}
+	})
+
+	t.Run("no width", func(t *testing.T) {
+		areas := []image.Rectangle{
+			image.Rect(10, 0, 10, 40),
+			{Min: image.Pt(20, 0), Max: image.Pt(10, 40)},
+		}
+		for _, area := range areas {
+			display, _ := newTestDisplay(t, newTestBuilder())
+			_, err := display.DrawTextWrapped(area, paragraph)
+			if !errors.Is(err, ErrTextTruncated) {
+				t.Errorf("Area %v: expected ErrTextTruncated, got %v", area, err)
+			}
+			if got := countOn(display.fb, display.fb.Bounds()); got != 0 {
+				t.Errorf("Area %v: expected nothing drawn, got %d lit pixels", area, got)
+			}
+		}
+	})
+
+	t.Run("without init", func(t *testing.T) {
+		display, err := newTestBuilder().WithDriver(NewTrackedFakeSSD1306()).Build()
+		assertNoError(t, err)
+		_, err = display.DrawTextWrapped(image.Rect(0, 0, 128, 64), paragraph)
+		assertError(t, err, "driver has not been initialized")
+	})
+}
+
+func TestDisplay_DrawText_UpdateShowsFramebuffer(t *testing.T) {
+	display, mock := newTestDisplay(t, newTestBuilder())
+
+	assertNoError(t, display.DrawText(image.Pt(0, 0), "Hi"))
+	assertNoError(t, display.Update())
+
+	_, src, _ := mock.LastDrawArgs()
+	if countOn(src, src.Bounds()) == 0 {
+		t.Error("Expected framebuffer content to be drawn by Update")
+	}
+}
+
+func TestDisplay_DrawLine(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder())
+
+	assertNoError(t, display.DrawLine(10, 5, 20, 5, true))
+	if got := countOn(display.fb, image.Rect(10, 5, 21, 6)); got != 11 {
+		t.Errorf("Expected 11 pixels on a horizontal line, got %d", got)
+	}
+
+	assertNoError(t, display.DrawLine(0, 0, 7, 7, true))
+	for i := range 8 {
+		if display.fb.BitAt(i, i) != image1bit.On {
+			t.Errorf("Expected diagonal pixel (%d,%d) to be lit", i, i)
+		}
+	}
+
+	assertNoError(t, display.DrawLine(10, 5, 20, 5, false))
+	if got := countOn(display.fb, image.Rect(10, 5, 21, 6)); got != 0 {
+		t.Errorf("Expected line to be erased, got %d lit pixels", got)
+	}
+
+	// Shallow slopes step in both x and y on some iterations.
+	assertNoError(t, display.DrawLine(11, 41, 27, 31, true))
+	if display.fb.BitAt(11, 41) != image1bit.On || display.fb.BitAt(27, 31) != image1bit.On {
+		t.Error("Expected both ends of a sloped line to be lit")
+	}
+}
+
+func TestDisplay_SetClip(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder())
+	bounds := display.fb.Bounds()
+
+	clip := image.Rect(10, 8, 40, 24)
+	assertNoError(t, display.SetClip(clip))
+	assertNoError(t, display.FillRect(bounds, true))
+	if got, want := countOn(display.fb, bounds), clip.Dx()*clip.Dy(); got != want {
+		t.Errorf("Expected only the %d clipped pixels to be on, got %d", want, got)
+	}
+	if got := countOn(display.fb, clip); got != clip.Dx()*clip.Dy() {
+		t.Errorf("Expected the whole clip region to be on, got %d pixels", got)
+	}
+
+	assertNoError(t, display.DrawLine(0, 0, bounds.Max.X-1, 0, true))
+	if got := countOn(display.fb, image.Rect(0, 0, bounds.Max.X, 1)); got != 0 {
+		t.Errorf("Expected line outside the clip region to be dropped, got %d pixels", got)
+	}
+
+	display.ClearClip()
+	assertNoError(t, display.FillRect(bounds, true))
+	if got := countOn(display.fb, bounds); got != bounds.Dx()*bounds.Dy() {
+		t.Errorf("Expected whole display to be on after ClearClip, got %d pixels", got)
+	}
+}
+
+func TestDisplay_DrawTextSnapped(t *testing.T) {
+	for _, border := range []bool{false, true} {
+		printed, printedMock := newTestDisplay(t, newTestBuilder().WithScreenBorder(border))
+		assertNoError(t, printed.PrintLine(2, "Hg"))
+		assertNoError(t, printed.Update())
+		_, want, _ := printedMock.LastDrawArgs()
+
+		snapped, snappedMock := newTestDisplay(t, newTestBuilder().WithScreenBorder(border))
+		assertNoError(t, snapped.DrawTextSnapped(2, "Hg"))
+		assertNoError(t, snapped.Update())
+		_, got, _ := snappedMock.LastDrawArgs()
+
+		if !bytes.Equal(got.(*image1bit.VerticalLSB).Pix, want.(*image1bit.VerticalLSB).Pix) {
+			t.Errorf("border=%v: expected snapped text to match PrintLine, got extent %v, want %v",
+				border, litExtent(got), litExtent(want))
+		}
+	}
+
+	display, _ := newTestDisplay(t, newTestBuilder())
+	assertError(t, display.DrawTextSnapped(5, "x"), "only has 5 lines")
+}
+
+func TestDisplay_DrawTextInRect(t *testing.T) {
+	r := image.Rect(20, 10, 100, 50)
+	center := func(e image.Rectangle) image.Point {
+		return e.Min.Add(e.Max).Div(2)
+	}
+
+	display, _ := newTestDisplay(t, newTestBuilder())
+	assertNoError(t, display.DrawTextInRect(r, "HI", AlignCenter, AlignMiddle))
+	extent := litExtent(display.fb)
+	if !extent.In(r) {
+		t.Fatalf("Expected text within %v, got %v", r, extent)
+	}
+	if got, want := center(extent), center(r); abs(got.X-want.X) > 1 || abs(got.Y-want.Y) > 2 {
+		t.Errorf("Expected text centered at %v, got %v (extent %v)", want, got, extent)
+	}
+
+	display, _ = newTestDisplay(t, newTestBuilder())
+	assertNoError(t, display.DrawTextInRect(r, "HI", AlignRight, AlignBottom))
+	extent = litExtent(display.fb)
+	if r.Max.X-extent.Max.X > 1 || r.Max.Y-extent.Max.Y > display.font.Metrics().Descent.Ceil() {
+		t.Errorf("Expected text in the bottom right of %v, got %v", r, extent)
+	}
+
+	display, _ = newTestDisplay(t, newTestBuilder())
+	assertNoError(t, display.DrawTextInRect(image.Rect(0, 0, 10, 5), "WIDE TEXT", AlignLeft, AlignTop))
+	if extent := litExtent(display.fb); !extent.In(image.Rect(0, 0, 10, 5)) {
+		t.Errorf("Expected text clipped to the rectangle, got %v", extent)
+	}
+}
+
+func TestDisplay_CopyRect(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder())
+
+	// Two lines in a band from y=16 to y=32.
+	band := image.Rect(0, 16, 128, 32)
+	assertNoError(t, display.DrawLine(0, 20, 127, 20, true))
+	assertNoError(t, display.DrawLine(0, 30, 127, 30, true))
+
+	// Copy the band up by 8 pixels, overlapping its own top half.
+	assertNoError(t, display.CopyRect(band, image.Pt(0, 8)))
+
+	// Row 30 is in the vacated strip at the bottom of the band and keeps
+	// its content.
+	lit := map[int]bool{12: true, 22: true, 30: true}
+	for y := range 40 {
+		if got, want := display.fb.BitAt(5, y), image1bit.Bit(lit[y]); got != want {
+			t.Errorf("Expected row %d to be %v, got %v", y, want, got)
+		}
+	}
+}
+
+func TestDisplay_CopyRect_PartlyOffScreen(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder())
+	display.setPixel(0, 3, image1bit.On)
+
+	// The source starts 4 pixels off the left edge, so its on-screen part
+	// lands 4 pixels to the right of dst.
+	assertNoError(t, display.CopyRect(image.Rect(-4, 0, 10, 8), image.Pt(20, 0)))
+	if display.fb.BitAt(24, 3) != image1bit.On {
+		t.Error("Expected pixel (0,3) to be copied to (24,3)")
+	}
+	if got := countOn(display.fb, image.Rect(11, 0, 128, 8)); got != 1 {
+		t.Errorf("Expected exactly one copied pixel, got %d", got)
+	}
+}
+
+func TestDisplay_StippleRect(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder())
+	r := image.Rect(10, 10, 50, 30)
+	total := r.Dx() * r.Dy()
+
+	tests := []struct {
+		density int
+		want    int
+	}{
+		{0, 0},
+		{25, total / 4},
+		{50, total / 2},
+		{100, total},
+	}
+	for _, tt := range tests {
+		assertNoError(t, display.StippleRect(r, tt.density))
+		if got := countOn(display.fb, r); got != tt.want {
+			t.Errorf("Density %d: expected %d of %d pixels lit, got %d", tt.density, tt.want, total, got)
+		}
+	}
+
+	if got := countOn(display.fb, display.fb.Bounds()); got != total {
+		t.Errorf("Expected pixels outside the rectangle to be untouched, got %d lit", got-total)
+	}
+
+	assertError(t, display.StippleRect(r, 101), "invalid density")
+}
+
+func TestDisplay_DrawGrid(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder())
+
+	assertError(t, display.DrawGrid(0, true), "invalid grid spacing")
+
+	assertNoError(t, display.DrawGrid(16, true))
+	for x := 0; x < 128; x += 16 {
+		if got := countOn(display.fb, image.Rect(x, 0, x+1, 64)); got != 64 {
+			t.Errorf("Expected column %d to be fully lit, got %d pixels", x, got)
+		}
+	}
+	for y := 0; y < 64; y += 16 {
+		if got := countOn(display.fb, image.Rect(0, y, 128, y+1)); got != 128 {
+			t.Errorf("Expected row %d to be fully lit, got %d pixels", y, got)
+		}
+	}
+	if display.fb.BitAt(8, 8) != image1bit.Off || display.fb.BitAt(17, 1) != image1bit.Off {
+		t.Error("Expected pixels between grid lines to be dark")
+	}
+}
+
+func TestDisplay_RectPct(t *testing.T) {
+	tests := []struct {
+		name         string
+		bounds       image.Rectangle
+		top, quarter image.Rectangle
+	}{
+		{"128x64", image.Rect(0, 0, 128, 64), image.Rect(0, 0, 128, 32), image.Rect(32, 16, 64, 32)},
+		{"128x32", image.Rect(0, 0, 128, 32), image.Rect(0, 0, 128, 16), image.Rect(32, 8, 64, 16)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			driver := &sizedSSD1306{TrackedFakeSSD1306: NewTrackedFakeSSD1306(), bounds: tt.bounds}
+			display, err := newTestBuilder().WithDriver(driver).WithLines(2).Build()
+			assertNoError(t, err)
+			assertNoError(t, display.Init())
+
+			if got := display.RectPct(0, 0, 1, 0.5); got != tt.top {
+				t.Errorf("Expected top half %v, got %v", tt.top, got)
+			}
+			if got := display.RectPct(0.25, 0.25, 0.25, 0.25); got != tt.quarter {
+				t.Errorf("Expected %v, got %v", tt.quarter, got)
+			}
+		})
+	}
+}
+
+func TestDisplay_InvertRegion(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder())
+	assertNoError(t, display.StippleRect(display.fb.Bounds(), 50))
+	before := image1bit.NewVerticalLSB(display.fb.Bounds())
+	copy(before.Pix, display.fb.Pix)
+
+	region := image.Rect(10, 5, 30, 20)
+	assertNoError(t, display.InvertRegion(region))
+
+	bounds := display.fb.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			want := before.BitAt(x, y)
+			if image.Pt(x, y).In(region) {
+				want = !want
+			}
+			if got := display.fb.BitAt(x, y); got != want {
+				t.Fatalf("Expected pixel (%d,%d) to be %v, got %v", x, y, want, got)
+			}
+		}
+	}
+
+	// Regions reaching past the display are clipped rather than panicking.
+	assertNoError(t, display.InvertRegion(image.Rect(120, 60, 200, 100)))
+	if got := countOn(display.fb, image.Rect(120, 60, 128, 64)); got != 32-countOn(before, image.Rect(120, 60, 128, 64)) {
+		t.Errorf("Expected the visible corner to be inverted, got %d lit pixels", got)
+	}
+}
+
+func TestDisplay_DrawRoundedRect(t *testing.T) {
+	r := image.Rect(10, 10, 50, 40)
+	corners := []image.Point{{10, 10}, {49, 10}, {10, 39}, {49, 39}}
+	edges := []image.Point{{30, 10}, {30, 39}, {10, 25}, {49, 25}}
+
+	for _, fill := range []bool{false, true} {
+		display, _ := newTestDisplay(t, newTestBuilder())
+		assertNoError(t, display.DrawRoundedRect(r, 6, true, fill))
+
+		for _, p := range corners {
+			if display.fb.BitAt(p.X, p.Y) {
+				t.Errorf("fill=%v: expected corner %v to be rounded away", fill, p)
+			}
+		}
+		for _, p := range edges {
+			if !display.fb.BitAt(p.X, p.Y) {
+				t.Errorf("fill=%v: expected edge pixel %v to be lit", fill, p)
+			}
+		}
+		if got := display.fb.BitAt(30, 25); bool(got) != fill {
+			t.Errorf("fill=%v: expected center to be %v, got %v", fill, fill, got)
+		}
+		if got := countOn(display.fb, display.fb.Bounds()) - countOn(display.fb, r); got != 0 {
+			t.Errorf("fill=%v: expected nothing drawn outside %v, got %d pixels", fill, r, got)
+		}
+	}
+
+	// A radius larger than the rectangle allows is clamped, and shapes
+	// reaching past the display are clipped.
+	display, _ := newTestDisplay(t, newTestBuilder())
+	assertNoError(t, display.DrawRoundedRect(image.Rect(100, 40, 140, 80), 100, true, true))
+	if countOn(display.fb, display.fb.Bounds()) == 0 {
+		t.Error("Expected the visible part of the rectangle to be drawn")
+	}
+	assertError(t, display.DrawRoundedRect(r, -1, true, false), "invalid radius")
+}
+
+func TestDisplay_DrawLine_Slopes(t *testing.T) {
+	tests := []struct {
+		name           string
+		x0, y0, x1, y1 int
+	}{
+		{"shallow rising", 11, 41, 27, 31},
+		{"steep falling", 5, 2, 9, 40},
+		{"steep rising", 60, 50, 52, 3},
+		{"shallow reversed", 100, 10, 70, 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			display, _ := newTestDisplay(t, newTestBuilder())
+
+			done := make(chan error, 1)
+			go func() {
+				done <- display.DrawLine(tt.x0, tt.y0, tt.x1, tt.y1, true)
+			}()
+			select {
+			case err := <-done:
+				assertNoError(t, err)
+			case <-time.After(time.Second):
+				t.Fatal("DrawLine did not return")
+			}
+
+			want := max(abs(tt.x1-tt.x0), abs(tt.y1-tt.y0)) + 1
+			if got := countOn(display.fb, display.fb.Bounds()); got != want {
+				t.Errorf("Expected %d lit pixels, got %d", want, got)
+			}
+			for _, p := range []image.Point{{tt.x0, tt.y0}, {tt.x1, tt.y1}} {
+				if display.fb.BitAt(p.X, p.Y) != image1bit.On {
+					t.Errorf("Expected end point %v to be lit", p)
+				}
+			}
+		})
+	}
+}
+
+func TestDisplay_DrawArc(t *testing.T) {
+	const cx, cy, r = 64, 32, 20
+
+	tests := []struct {
+		name       string
+		start, end float64
+		lit        []image.Rectangle
+		dark       []image.Rectangle
+	}{
+		{
+			name:  "first quadrant",
+			start: 0,
+			end:   90,
+			lit:   []image.Rectangle{image.Rect(cx, cy, cx+r+1, cy+r+1)},
+			dark: []image.Rectangle{
+				image.Rect(0, 0, 128, cy),
+				image.Rect(0, 0, cx, 64),
+			},
+		},
+		{
+			name:  "wraps through zero",
+			start: 350,
+			end:   10,
+			lit: []image.Rectangle{
+				image.Rect(cx+r-1, cy-4, cx+r+1, cy),
+				image.Rect(cx+r-1, cy+1, cx+r+1, cy+5),
+			},
+			dark: []image.Rectangle{
+				image.Rect(0, 0, cx+r-5, 64),
+			},
+		},
+		{
+			name:  "full circle",
+			start: 0,
+			end:   360,
+			lit: []image.Rectangle{
+				image.Rect(cx-r, cy-1, cx-r+1, cy+2),
+				image.Rect(cx-1, cy-r, cx+2, cy-r+1),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			display, _ := newTestDisplay(t, newTestBuilder())
+			assertNoError(t, display.DrawArc(cx, cy, r, tt.start, tt.end, true))
+
+			for _, rect := range tt.lit {
+				if countOn(display.fb, rect) == 0 {
+					t.Errorf("Expected lit pixels in %v", rect)
+				}
+			}
+			for _, rect := range tt.dark {
+				if got := countOn(display.fb, rect); got != 0 {
+					t.Errorf("Expected no lit pixels in %v, got %d", rect, got)
+				}
+			}
+		})
+	}
+}
+
+func TestDisplay_DrawArc_Clipped(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder())
+
+	// An arc that extends past the edges of the display must not panic.
+	assertNoError(t, display.DrawArc(0, 0, 100, 0, 360, true))
+	if countOn(display.fb, display.fb.Bounds()) == 0 {
+		t.Error("Expected the visible part of the arc to be drawn")
+	}
+}
+
+func TestDisplay_DrawSector(t *testing.T) {
+	const cx, cy, r = 64, 32, 20
+	display, _ := newTestDisplay(t, newTestBuilder())
+
+	assertNoError(t, display.DrawSector(cx, cy, r, 0, 90, true))
+
+	// Both radii are drawn: along 3 o'clock and along 6 o'clock.
+	if got := countOn(display.fb, image.Rect(cx, cy, cx+r+1, cy+1)); got != r+1 {
+		t.Errorf("Expected horizontal radius of %d pixels, got %d", r+1, got)
+	}
+	if got := countOn(display.fb, image.Rect(cx, cy, cx+1, cy+r+1)); got != r+1 {
+		t.Errorf("Expected vertical radius of %d pixels, got %d", r+1, got)
+	}
+}
+
+// litExtent returns the smallest rectangle containing every lit pixel of img.
+func litExtent(img image.Image) image.Rectangle {
+	var extent image.Rectangle
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if img.At(x, y) == image1bit.On {
+				extent = extent.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return extent
+}
+
+func TestDisplay_WithLetterSpacing(t *testing.T) {
+	widthWithSpacing := func(spacing int) int {
+		display, mock := newTestDisplay(t, newTestBuilder().WithLetterSpacing(spacing))
+		assertNoError(t, display.PrintLine(0, "HHHH"))
+		assertNoError(t, display.Update())
+		_, src, _ := mock.LastDrawArgs()
+		return litExtent(src).Dx()
+	}
+
+	normal := widthWithSpacing(0)
+
+	if wide := widthWithSpacing(3); wide != normal+9 {
+		t.Errorf("Expected spaced text to be %d pixels wide, got %d", normal+9, wide)
+	}
+
+	if tight := widthWithSpacing(-1); tight != normal-3 {
+		t.Errorf("Expected tightened text to be %d pixels wide, got %d", normal-3, tight)
+	}
+
+	// Glyphs always advance, even with extreme negative spacing.
+	if squashed := widthWithSpacing(-100); squashed <= 0 || squashed >= normal {
+		t.Errorf("Expected squashed text to be narrower than %d but not empty, got %d", normal, squashed)
+	}
+}
+
+func TestDisplay_LetterSpacing_WrappedAndKV(t *testing.T) {
+	const spacing = 3
+	face := basicfont.Face7x13
+
+	// Spacing is allowed for when wrapping.
+	if got := wrapText(face, "the quick brown", 80, 0); len(got) != 2 {
+		t.Errorf("Expected the text to wrap onto 2 lines, got %q", got)
+	}
+	if got := wrapText(face, "the quick brown", 80, spacing); len(got) != 3 {
+		t.Errorf("Expected the spaced text to wrap onto 3 lines, got %q", got)
+	}
+
+	// DrawTextWrapped and DrawKV space their text like DrawText does.
+	spaced, _ := newTestDisplay(t, newTestBuilder().WithLetterSpacing(spacing))
+	assertNoError(t, spaced.DrawText(image.Pt(0, 0), "1234"))
+	want := litExtent(spaced.fb).Dx()
+
+	wrapped, _ := newTestDisplay(t, newTestBuilder().WithLetterSpacing(spacing))
+	_, err := wrapped.DrawTextWrapped(image.Rect(0, 0, 128, 64), "1234")
+	assertNoError(t, err)
+	if got := litExtent(wrapped.fb).Dx(); got != want {
+		t.Errorf("Expected wrapped text %d pixels wide, got %d", want, got)
+	}
+
+	kv, _ := newTestDisplay(t, newTestBuilder().WithLetterSpacing(spacing))
+	assertNoError(t, kv.DrawKV([]KV{{Value: "1234"}}))
+	extent := litExtent(kv.fb)
+	if extent.Dx() != want {
+		t.Errorf("Expected the value %d pixels wide, got %d", want, extent.Dx())
+	}
+	if extent.Max.X > 128 {
+		t.Errorf("Expected the value to end on the display, got x=%d", extent.Max.X)
+	}
+}
+
+func TestDisplay_DrawKV(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder())
+
+	pairs := []KV{
+		{Key: "CPU", Value: "42%"},
+		{Key: "Temperature", Value: "51C"},
+		{Key: "A very long label indeed", Value: "ok"},
+	}
+	assertNoError(t, display.DrawKV(pairs))
+
+	cell := display.cellWidth()
+	for i := range pairs {
+		extent := litExtent(clippedImage{Image: display.fb, clip: display.lineRect(i)})
+		if extent.Min.X > 1 {
+			t.Errorf("Expected key on line %d to start at the left edge, got x=%d", i, extent.Min.X)
+		}
+		if extent.Max.X < 128-cell {
+			t.Errorf("Expected value on line %d to end near the right edge, got x=%d", i, extent.Max.X)
+		}
+	}
+
+	// The long key is truncated so that a gap remains before its value.
+	valueStart := 128 - font.MeasureString(display.font, "ok").Ceil()
+	gap := image.Rect(valueStart-cell, 0, valueStart, 64).Intersect(display.lineRect(2))
+	if got := countOn(display.fb, gap); got != 0 {
+		t.Errorf("Expected truncated key to stay clear of its value, got %d lit pixels", got)
+	}
+
+	many := make([]KV, 7)
+	assertError(t, display.DrawKV(many), "drew 5 of 7 pairs")
+}
+
+func TestDisplay_WithTextOutline(t *testing.T) {
+	at := image.Pt(20, 20)
+
+	plain, _ := newTestDisplay(t, newTestBuilder())
+	assertNoError(t, plain.DrawText(at, "I"))
+	glyph := plain.fb
+
+	display, _ := newTestDisplay(t, newTestBuilder().WithTextOutline(true))
+	assertNoError(t, display.DrawImageAt(newFilledImage(128, 64, 255), image.Point{}))
+	assertNoError(t, display.DrawText(at, "I"))
+
+	extent := litExtent(glyph)
+	outlined := 0
+	for y := extent.Min.Y - 1; y <= extent.Max.Y; y++ {
+		for x := extent.Min.X - 1; x <= extent.Max.X; x++ {
+			if glyph.BitAt(x, y) == image1bit.On {
+				if display.fb.BitAt(x, y) != image1bit.On {
+					t.Errorf("Expected glyph pixel (%d,%d) to be lit", x, y)
+				}
+				continue
+			}
+
+			nearGlyph := false
+			for _, n := range []image.Point{{-1, -1}, {0, -1}, {1, -1}, {-1, 0}, {1, 0}, {-1, 1}, {0, 1}, {1, 1}} {
+				if glyph.BitAt(x+n.X, y+n.Y) == image1bit.On {
+					nearGlyph = true
+				}
+			}
+			if nearGlyph {
+				outlined++
+				if display.fb.BitAt(x, y) != image1bit.Off {
+					t.Errorf("Expected outline pixel (%d,%d) to be dark", x, y)
+				}
+			}
+		}
+	}
+	if outlined == 0 {
+		t.Fatal("Expected outline pixels around the glyph")
+	}
+
+	if display.fb.BitAt(0, 0) != image1bit.On {
+		t.Error("Expected the background away from the text to be untouched")
+	}
+}
@@ -2,18 +2,25 @@ package fakedriver
 
 import (
 	"bytes"
+	"context"
 	"embed"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"html/template"
 	"image"
 	"image/color"
 	"image/png"
 	"log"
+	"maps"
+	"net"
 	"net/http"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"periph.io/x/devices/v3/ssd1306/image1bit"
 )
@@ -26,14 +33,45 @@ type FakeSSD1306 struct {
 	mutex         sync.Mutex
 	buffer        *image.RGBA
 	server        *http.Server
+	listener      net.Listener
 	listenAddress string
 	port          uint
 	clients       map[chan string]bool
 	waitMode      bool
 	startChan     chan bool
 	started       bool
+	startedChan   chan struct{}
+	startOnce     sync.Once
+	viewerChan    chan struct{}
+	keepAlive     time.Duration
+	paused        bool
+	persist       bool
+	headless      bool
+	controls      map[string]func()
+	inverted      bool
+	contrast      byte
+
+	// With a refresh interval, Draw only marks the frame as pending and a
+	// background loop pushes it to clients.
+	refreshInterval time.Duration
+	pending         bool
+	stopRefresh     chan struct{}
 }
 
+// State is a snapshot of the simulated display, served as JSON at /state.
+type State struct {
+	Width    int  `json:"width"`
+	Height   int  `json:"height"`
+	Started  bool `json:"started"`
+	Clients  int  `json:"clients"`
+	Inverted bool `json:"inverted"`
+	Contrast byte `json:"contrast"`
+}
+
+const (
+	DEFAULT_KEEPALIVE = 15 * time.Second
+)
+
 func getEnvWithDefault(name, defval string) string {
 	val := os.Getenv(name)
 	if val == "" {
@@ -57,7 +95,12 @@ func NewFakeSSD1306() *FakeSSD1306 {
 		listenAddress: listenAddress,
 		port:          uint(port),
 		clients:       make(map[chan string]bool),
+		controls:      make(map[string]func()),
 		startChan:     make(chan bool, 1),
+		startedChan:   make(chan struct{}),
+		viewerChan:    make(chan struct{}),
+		keepAlive:     DEFAULT_KEEPALIVE,
+		contrast:      0xFF,
 	}
 }
 
@@ -71,6 +114,36 @@ func (f *FakeSSD1306) WithListenAddress(addr string) *FakeSSD1306 {
 	return f
 }
 
+// WithKeepAlive sets the interval at which keepalive comments are sent to
+// live view clients so that idle connections are not dropped by proxies. An
+// interval of zero disables keepalives.
+func (f *FakeSSD1306) WithKeepAlive(interval time.Duration) *FakeSSD1306 {
+	f.keepAlive = interval
+	return f
+}
+
+// WithPersistAfterClose keeps the HTTP server running after Close so that the
+// final frame can still be inspected. Call Shutdown to stop the server.
+func (f *FakeSSD1306) WithPersistAfterClose(persist bool) *FakeSSD1306 {
+	f.persist = persist
+	return f
+}
+
+// WithRefreshInterval coalesces rapid draws so that live view clients are
+// sent at most one frame per interval, always the latest one. An interval of
+// zero pushes every frame as it is drawn.
+func (f *FakeSSD1306) WithRefreshInterval(interval time.Duration) *FakeSSD1306 {
+	f.refreshInterval = interval
+	return f
+}
+
+// WithHeadless disables the HTTP server, so that Open only allocates the
+// display buffer. Frames can still be inspected with CurrentImage.
+func (f *FakeSSD1306) WithHeadless(headless bool) *FakeSSD1306 {
+	f.headless = headless
+	return f
+}
+
 func (d *FakeSSD1306) SetWaitMode(waitMode bool) {
 	d.waitMode = waitMode
 }
@@ -80,9 +153,48 @@ func (d *FakeSSD1306) IsWaitMode() bool {
 }
 
 func (d *FakeSSD1306) WaitForStart() {
-	if d.waitMode && !d.started {
+	d.mutex.Lock()
+	started := d.started
+	d.mutex.Unlock()
+
+	if d.waitMode && !started {
 		<-d.startChan
+		d.mutex.Lock()
 		d.started = true
+		d.mutex.Unlock()
+	}
+}
+
+// WithControlCallback adds a button labelled name to the simulator page.
+// Clicking it, or posting to /control/<name>, calls fn.
+func (d *FakeSSD1306) WithControlCallback(name string, fn func()) *FakeSSD1306 {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.controls[name] = fn
+	return d
+}
+
+// StartedChan returns a channel that is closed when the start button in the
+// browser is clicked, for use in a select instead of blocking in
+// WaitForStart.
+func (d *FakeSSD1306) StartedChan() <-chan struct{} {
+	return d.startedChan
+}
+
+// WaitForViewer blocks until a live view client is connected to the event
+// stream or ctx is cancelled, in which case it returns the context's error.
+// Once the last client has disconnected it blocks again until another one
+// connects.
+func (d *FakeSSD1306) WaitForViewer(ctx context.Context) error {
+	d.mutex.Lock()
+	viewer := d.viewerChan
+	d.mutex.Unlock()
+
+	select {
+	case <-viewer:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
 
@@ -90,31 +202,33 @@ func (d *FakeSSD1306) Open() error {
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
 
-	// Initialize the buffer
-	d.buffer = image.NewRGBA(d.bounds)
+	d.resetBuffer()
 
-	// Fill with black (OLED background)
-	for y := d.bounds.Min.Y; y < d.bounds.Max.Y; y++ {
-		for x := d.bounds.Min.X; x < d.bounds.Max.X; x++ {
-			d.buffer.Set(x, y, color.RGBA{0, 0, 0, 255})
-		}
+	if d.headless {
+		return nil
 	}
 
 	// Set up HTTP server
-	mux := http.NewServeMux()
-	mux.HandleFunc("/", d.handleDisplay)
-	mux.HandleFunc("/events", d.handleSSE)
-	mux.HandleFunc("/start", d.handleStart)
-
+	addr := fmt.Sprintf("%s:%d", d.listenAddress, d.port)
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	d.listener = listener
 	d.server = &http.Server{
-		Addr:    fmt.Sprintf("%s:%d", d.listenAddress, d.port),
-		Handler: mux,
+		Handler: d.handler(),
+	}
+
+	if d.refreshInterval > 0 {
+		d.stopRefresh = make(chan struct{})
+		go d.refreshLoop(d.refreshInterval, d.stopRefresh)
 	}
 
 	// Start server in a goroutine
+	server := d.server
 	go func() {
-		log.Printf("SSD1306 Display Simulator running at http://localhost:%d", d.port)
-		if err := d.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Printf("SSD1306 Display Simulator running at http://%s", listener.Addr())
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
 			log.Printf("HTTP server error: %v", err)
 		}
 	}()
@@ -122,13 +236,66 @@ func (d *FakeSSD1306) Open() error {
 	return nil
 }
 
+// Addr returns the address the HTTP server is listening on, or an empty
+// string if it is not running.
+func (d *FakeSSD1306) Addr() string {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.server == nil {
+		return ""
+	}
+	return d.listener.Addr().String()
+}
+
+// resetBuffer allocates a blank display buffer. The caller must hold the
+// mutex.
+func (d *FakeSSD1306) resetBuffer() {
+	d.buffer = image.NewRGBA(d.bounds)
+
+	// Fill with black (OLED background)
+	for y := d.bounds.Min.Y; y < d.bounds.Max.Y; y++ {
+		for x := d.bounds.Min.X; x < d.bounds.Max.X; x++ {
+			d.buffer.Set(x, y, color.RGBA{0, 0, 0, 255})
+		}
+	}
+}
+
+func (d *FakeSSD1306) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", d.handleDisplay)
+	mux.HandleFunc("/events", d.handleSSE)
+	mux.HandleFunc("/frame.png", d.handleFrame)
+	mux.HandleFunc("/start", d.handleStart)
+	mux.HandleFunc("/control/", d.handleControl)
+	mux.HandleFunc("/state", d.handleState)
+	return mux
+}
+
+// Close stops the HTTP server, unless WithPersistAfterClose is set.
 func (d *FakeSSD1306) Close() error {
+	if d.persist {
+		return nil
+	}
+	return d.Shutdown()
+}
+
+// Shutdown stops the HTTP server. It is safe to call more than once, and
+// concurrently with live view clients.
+func (d *FakeSSD1306) Shutdown() error {
 	d.mutex.Lock()
 	defer d.mutex.Unlock()
 
 	if d.server != nil {
-		// Clear clients map without closing channels to avoid panic
+		// Forget all clients without closing their channels; each
+		// handleSSE closes its own channel when its connection ends.
 		d.clients = make(map[chan string]bool)
+		d.rearmViewer()
+
+		if d.stopRefresh != nil {
+			close(d.stopRefresh)
+			d.stopRefresh = nil
+		}
 
 		// Force close the server immediately - don't wait for graceful shutdown
 		err := d.server.Close()
@@ -138,6 +305,67 @@ func (d *FakeSSD1306) Close() error {
 	return nil
 }
 
+// CurrentImage returns a copy of the frame currently shown on the display, or
+// nil if the display has not been opened.
+func (d *FakeSSD1306) CurrentImage() image.Image {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if d.buffer == nil {
+		return nil
+	}
+	img := image.NewRGBA(d.buffer.Bounds())
+	copy(img.Pix, d.buffer.Pix)
+	return img
+}
+
+// SetContrast records the contrast level. It is reported by State but does
+// not change the live view.
+func (d *FakeSSD1306) SetContrast(level byte) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.contrast = level
+	return nil
+}
+
+// Invert records whether the panel is inverted. It is reported by State but
+// does not change the live view.
+func (d *FakeSSD1306) Invert(blackOnWhite bool) error {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.inverted = blackOnWhite
+	return nil
+}
+
+// State returns a snapshot of the simulated display.
+func (d *FakeSSD1306) State() State {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	started := d.started
+	select {
+	case <-d.startedChan:
+		started = true
+	default:
+	}
+
+	return State{
+		Width:    d.bounds.Dx(),
+		Height:   d.bounds.Dy(),
+		Started:  started,
+		Clients:  len(d.clients),
+		Inverted: d.inverted,
+		Contrast: d.contrast,
+	}
+}
+
+// ClientCount returns the number of connected live view clients.
+func (d *FakeSSD1306) ClientCount() int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return len(d.clients)
+}
+
 func (d *FakeSSD1306) Bounds() image.Rectangle {
 	return d.bounds
 }
@@ -178,11 +406,39 @@ func (d *FakeSSD1306) Draw(r image.Rectangle, src image.Image, sp image.Point) e
 	}
 
 	// Notify all connected clients of the update
-	d.notifyClients()
+	switch {
+	case d.refreshInterval > 0:
+		d.pending = true
+	case !d.paused:
+		d.notifyClients()
+	}
 
 	return nil
 }
 
+// PauseUpdates stops pushing frames to live view clients. Draw continues to
+// update the display buffer.
+func (d *FakeSSD1306) PauseUpdates() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.paused = true
+}
+
+// ResumeUpdates resumes pushing frames to live view clients and immediately
+// pushes the current frame.
+func (d *FakeSSD1306) ResumeUpdates() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if !d.paused {
+		return
+	}
+	d.paused = false
+	if d.buffer != nil {
+		d.pending = false
+		d.notifyClients()
+	}
+}
+
 func (d *FakeSSD1306) notifyClients() {
 	// Convert buffer to base64 PNG for SSE
 	var buf bytes.Buffer
@@ -196,13 +452,55 @@ func (d *FakeSSD1306) notifyClients() {
 		select {
 		case client <- "image:" + b64:
 		default:
-			// Client channel is full or closed, remove it
-			close(client)
-			delete(d.clients, client)
+			d.dropClient(client)
+		}
+	}
+}
+
+// refreshLoop pushes the latest frame to clients once per interval if it has
+// changed, until stop is closed.
+func (d *FakeSSD1306) refreshLoop(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			d.mutex.Lock()
+			if d.pending && !d.paused {
+				d.pending = false
+				d.notifyClients()
+			}
+			d.mutex.Unlock()
+		case <-stop:
+			return
 		}
 	}
 }
 
+// dropClient disconnects a client that is not keeping up. Closing the
+// channel makes its handleSSE return. The caller must hold the mutex.
+func (d *FakeSSD1306) dropClient(client chan string) {
+	delete(d.clients, client)
+	close(client)
+	d.rearmViewer()
+}
+
+// rearmViewer replaces the closed viewer channel with an open one once no
+// clients are left, so that WaitForViewer blocks again. The caller must hold
+// the mutex.
+func (d *FakeSSD1306) rearmViewer() {
+	select {
+	case <-d.viewerChan:
+		if len(d.clients) == 0 {
+			d.viewerChan = make(chan struct{})
+		}
+	default:
+	}
+}
+
+// notifyStatus sends the start status to all clients. The caller must hold
+// the mutex.
 func (d *FakeSSD1306) notifyStatus() {
 	status := "waiting"
 	if d.started {
@@ -214,9 +512,7 @@ func (d *FakeSSD1306) notifyStatus() {
 		select {
 		case client <- "status:" + status:
 		default:
-			// Client channel is full or closed, remove it
-			close(client)
-			delete(d.clients, client)
+			d.dropClient(client)
 		}
 	}
 }
@@ -248,10 +544,13 @@ func (d *FakeSSD1306) handleDisplay(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	controls := slices.Sorted(maps.Keys(d.controls))
 	data := struct {
 		ImageData string
+		Controls  []string
 	}{
 		ImageData: b64,
+		Controls:  controls,
 	}
 
 	w.Header().Set("Content-Type", "text/html")
@@ -260,6 +559,50 @@ func (d *FakeSSD1306) handleDisplay(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// handleControl calls the callback registered for the control named in the
+// request path.
+func (d *FakeSSD1306) handleControl(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimPrefix(r.URL.Path, "/control/")
+	d.mutex.Lock()
+	fn, ok := d.controls[name]
+	d.mutex.Unlock()
+	if !ok {
+		http.Error(w, fmt.Sprintf("Unknown control %q", name), http.StatusNotFound)
+		return
+	}
+
+	fn()
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleState serves the State of the display as JSON.
+func (d *FakeSSD1306) handleState(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(d.State()); err != nil {
+		log.Printf("failed to encode state: %v", err)
+	}
+}
+
+// handleFrame serves the current frame as a PNG image.
+func (d *FakeSSD1306) handleFrame(w http.ResponseWriter, r *http.Request) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, d.buffer); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(buf.Bytes()) //nolint:errcheck
+}
+
 func (d *FakeSSD1306) handleSSE(w http.ResponseWriter, r *http.Request) {
 	// Set SSE headers
 	w.Header().Set("Content-Type", "text/event-stream")
@@ -271,6 +614,9 @@ func (d *FakeSSD1306) handleSSE(w http.ResponseWriter, r *http.Request) {
 	clientChan := make(chan string, 10)
 
 	d.mutex.Lock()
+	if len(d.clients) == 0 {
+		close(d.viewerChan)
+	}
 	d.clients[clientChan] = true
 	d.mutex.Unlock()
 
@@ -293,14 +639,23 @@ func (d *FakeSSD1306) handleSSE(w http.ResponseWriter, r *http.Request) {
 	}
 	d.mutex.Unlock()
 
-	// Handle client disconnection
+	// Handle client disconnection. The channel may already have been
+	// closed by dropClient, or forgotten by Shutdown.
 	defer func() {
 		d.mutex.Lock()
-		delete(d.clients, clientChan)
-		d.mutex.Unlock()
-		close(clientChan)
+		defer d.mutex.Unlock()
+		if d.clients[clientChan] {
+			d.dropClient(clientChan)
+		}
 	}()
 
+	var keepAlive <-chan time.Time
+	if d.keepAlive > 0 {
+		ticker := time.NewTicker(d.keepAlive)
+		defer ticker.Stop()
+		keepAlive = ticker.C
+	}
+
 	// Stream updates to client
 	for {
 		select {
@@ -312,6 +667,11 @@ func (d *FakeSSD1306) handleSSE(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 			w.(http.Flusher).Flush()
+		case <-keepAlive:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
 		case <-r.Context().Done():
 			return
 		}
@@ -336,10 +696,15 @@ func (d *FakeSSD1306) handleStart(w http.ResponseWriter, r *http.Request) {
 	// Signal that start button was clicked
 	select {
 	case d.startChan <- true:
+		d.startOnce.Do(func() { close(d.startedChan) })
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("Started")) //nolint:errcheck
 		// Notify all clients of status change
-		go d.notifyStatus()
+		go func() {
+			d.mutex.Lock()
+			defer d.mutex.Unlock()
+			d.notifyStatus()
+		}()
 	default:
 		// Channel is full
 		w.WriteHeader(http.StatusConflict)
@@ -0,0 +1,495 @@
+package fakedriver
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"periph.io/x/devices/v3/ssd1306/image1bit"
+)
+
+// newTestServer opens the fake display and serves its handlers from a test
+// server rather than the configured listen address.
+func newTestServer(t *testing.T) (*FakeSSD1306, *httptest.Server) {
+	t.Helper()
+	d := NewFakeSSD1306()
+	d.mutex.Lock()
+	d.resetBuffer()
+	d.mutex.Unlock()
+
+	server := httptest.NewServer(d.handler())
+	t.Cleanup(server.Close)
+	return d, server
+}
+
+// connectSSE opens an event stream to the server and returns a reader for
+// the stream. The connection is closed when the test finishes.
+func connectSSE(t *testing.T, server *httptest.Server) *bufio.Reader {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/events", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("Failed to connect to event stream: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() }) //nolint:errcheck
+
+	return bufio.NewReader(resp.Body)
+}
+
+// streamLines returns a channel delivering each non-empty line read from the
+// event stream. The channel is closed when the stream ends.
+func streamLines(stream *bufio.Reader) <-chan string {
+	lines := make(chan string, 100)
+	go func() {
+		defer close(lines)
+		for {
+			line, err := stream.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if line = strings.TrimSuffix(line, "\n"); line != "" {
+				lines <- line
+			}
+		}
+	}()
+	return lines
+}
+
+// nextEvent returns the next line from the event stream, or false if none
+// arrives within the timeout.
+func nextEvent(lines <-chan string, timeout time.Duration) (string, bool) {
+	select {
+	case line, ok := <-lines:
+		return line, ok
+	case <-time.After(timeout):
+		return "", false
+	}
+}
+
+// waitFor polls cond until it returns true or the timeout expires.
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) bool {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return true
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return cond()
+}
+
+func TestFakeSSD1306_ClientCount(t *testing.T) {
+	d, server := newTestServer(t)
+
+	if got := d.ClientCount(); got != 0 {
+		t.Fatalf("Expected no clients, got %d", got)
+	}
+
+	stream := connectSSE(t, server)
+
+	if !waitFor(t, time.Second, func() bool { return d.ClientCount() == 1 }) {
+		t.Fatalf("Expected 1 client, got %d", d.ClientCount())
+	}
+
+	line, err := stream.ReadString('\n')
+	if err != nil {
+		t.Fatalf("Failed to read from event stream: %v", err)
+	}
+	if !strings.HasPrefix(line, "data: status:") {
+		t.Errorf("Expected initial status event, got %q", line)
+	}
+}
+
+func TestFakeSSD1306_KeepAlive(t *testing.T) {
+	d, server := newTestServer(t)
+	d.WithKeepAlive(20 * time.Millisecond)
+
+	lines := streamLines(connectSSE(t, server))
+
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				t.Fatal("Event stream closed before a keepalive was received")
+			}
+			if line == ": keepalive" {
+				return
+			}
+		case <-timeout:
+			t.Fatal("Expected a keepalive comment on the event stream")
+		}
+	}
+}
+
+func TestFakeSSD1306_PauseUpdates(t *testing.T) {
+	d, server := newTestServer(t)
+	lines := streamLines(connectSSE(t, server))
+
+	// Consume the initial status and image events.
+	for range 2 {
+		if _, ok := nextEvent(lines, time.Second); !ok {
+			t.Fatal("Expected initial events on the event stream")
+		}
+	}
+
+	frame := image1bit.NewVerticalLSB(d.Bounds())
+	frame.SetBit(0, 0, image1bit.On)
+
+	d.PauseUpdates()
+	for range 3 {
+		if err := d.Draw(d.Bounds(), frame, image.Point{}); err != nil {
+			t.Fatalf("Draw failed: %v", err)
+		}
+	}
+
+	if d.buffer.RGBAAt(0, 0) != (color.RGBA{255, 255, 255, 255}) {
+		t.Error("Expected Draw to update the buffer while paused")
+	}
+
+	if line, ok := nextEvent(lines, 50*time.Millisecond); ok {
+		t.Fatalf("Expected no events while paused, got %.20q", line)
+	}
+
+	d.ResumeUpdates()
+
+	line, ok := nextEvent(lines, time.Second)
+	if !ok || !strings.HasPrefix(line, "data: image:") {
+		t.Fatalf("Expected an image event on resume, got %.20q", line)
+	}
+	if line, ok := nextEvent(lines, 50*time.Millisecond); ok {
+		t.Errorf("Expected a single image event on resume, got %.20q", line)
+	}
+}
+
+func TestFakeSSD1306_StartedChan(t *testing.T) {
+	d, server := newTestServer(t)
+
+	select {
+	case <-d.StartedChan():
+		t.Fatal("Expected StartedChan not to signal before start is clicked")
+	default:
+	}
+
+	resp, err := http.Post(server.URL+"/start", "text/plain", nil)
+	if err != nil {
+		t.Fatalf("Failed to post to /start: %v", err)
+	}
+	resp.Body.Close() //nolint:errcheck
+
+	select {
+	case <-d.StartedChan():
+	case <-time.After(time.Second):
+		t.Fatal("Expected StartedChan to signal after start is clicked")
+	}
+}
+
+func TestFakeSSD1306_State(t *testing.T) {
+	d, server := newTestServer(t)
+	if err := d.SetContrast(0x40); err != nil {
+		t.Fatalf("SetContrast failed: %v", err)
+	}
+	if err := d.Invert(true); err != nil {
+		t.Fatalf("Invert failed: %v", err)
+	}
+
+	resp, err := http.Get(server.URL + "/state")
+	if err != nil {
+		t.Fatalf("Failed to get /state: %v", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	var state State
+	if err := json.NewDecoder(resp.Body).Decode(&state); err != nil {
+		t.Fatalf("Failed to decode state: %v", err)
+	}
+
+	bounds := d.Bounds()
+	want := State{Width: bounds.Dx(), Height: bounds.Dy(), Inverted: true, Contrast: 0x40}
+	if state != want {
+		t.Errorf("Expected state %+v, got %+v", want, state)
+	}
+}
+
+func TestFakeSSD1306_WithControlCallback(t *testing.T) {
+	called := make(chan struct{}, 1)
+	d, server := newTestServer(t)
+	d.WithControlCallback("reset", func() { called <- struct{}{} })
+
+	resp, err := http.Post(server.URL+"/control/reset", "text/plain", nil)
+	if err != nil {
+		t.Fatalf("Failed to post to /control/reset: %v", err)
+	}
+	resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	select {
+	case <-called:
+	default:
+		t.Error("Expected the reset callback to run")
+	}
+
+	resp, err = http.Post(server.URL+"/control/pause", "text/plain", nil)
+	if err != nil {
+		t.Fatalf("Failed to post to /control/pause: %v", err)
+	}
+	resp.Body.Close() //nolint:errcheck
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 for an unregistered control, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get(server.URL + "/")
+	if err != nil {
+		t.Fatalf("Failed to get page: %v", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	page, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(page), `data-control="reset"`) {
+		t.Error("Expected the page to include a reset button")
+	}
+}
+
+func TestFakeSSD1306_WithPersistAfterClose(t *testing.T) {
+	d := NewFakeSSD1306().WithListenAddress("127.0.0.1").WithPort(0).WithPersistAfterClose(true)
+	if err := d.Open(); err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	t.Cleanup(func() { d.Shutdown() }) //nolint:errcheck
+
+	frame := image1bit.NewVerticalLSB(d.Bounds())
+	frame.SetBit(3, 4, image1bit.On)
+	if err := d.Draw(d.Bounds(), frame, image.Point{}); err != nil {
+		t.Fatalf("Draw failed: %v", err)
+	}
+
+	addr := d.Addr()
+	if err := d.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	resp, err := http.Get("http://" + addr + "/frame.png")
+	if err != nil {
+		t.Fatalf("Expected server to be reachable after Close: %v", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	img, err := png.Decode(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to decode frame: %v", err)
+	}
+	if r, _, _, _ := img.At(3, 4).RGBA(); r == 0 {
+		t.Error("Expected the final frame to be served after Close")
+	}
+
+	if err := d.Shutdown(); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if d.Addr() != "" {
+		t.Error("Expected no address after Shutdown")
+	}
+}
+
+func TestFakeSSD1306_CloseStopsServer(t *testing.T) {
+	d := NewFakeSSD1306().WithListenAddress("127.0.0.1").WithPort(0)
+	if err := d.Open(); err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	addr := d.Addr()
+	if err := d.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if resp, err := http.Get("http://" + addr + "/frame.png"); err == nil {
+		resp.Body.Close() //nolint:errcheck
+		t.Error("Expected server to be unreachable after Close")
+	}
+}
+
+func TestFakeSSD1306_WithHeadless(t *testing.T) {
+	// Find a free port, then make sure the headless display does not bind it.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find a free port: %v", err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close() //nolint:errcheck
+
+	d := NewFakeSSD1306().WithListenAddress("127.0.0.1").WithPort(uint(port)).WithHeadless(true)
+	if err := d.Open(); err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer d.Close() //nolint:errcheck
+
+	if addr := d.Addr(); addr != "" {
+		t.Errorf("Expected no server in headless mode, got %s", addr)
+	}
+	l, err = net.Listen("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatalf("Expected port %d to be free in headless mode: %v", port, err)
+	}
+	l.Close() //nolint:errcheck
+
+	frame := image1bit.NewVerticalLSB(d.Bounds())
+	frame.SetBit(5, 6, image1bit.On)
+	if err := d.Draw(d.Bounds(), frame, image.Point{}); err != nil {
+		t.Fatalf("Draw failed: %v", err)
+	}
+	if r, _, _, _ := d.CurrentImage().At(5, 6).RGBA(); r == 0 {
+		t.Error("Expected Draw to record pixels in headless mode")
+	}
+}
+
+func TestFakeSSD1306_ConcurrentClose(t *testing.T) {
+	d := NewFakeSSD1306().WithListenAddress("127.0.0.1").WithPort(0)
+	if err := d.Open(); err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+
+	resp, err := http.Get("http://" + d.Addr() + "/events")
+	if err != nil {
+		t.Fatalf("Failed to connect to event stream: %v", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	lines := streamLines(bufio.NewReader(resp.Body))
+	if _, ok := nextEvent(lines, time.Second); !ok {
+		t.Fatal("Expected an initial event")
+	}
+
+	// Keep drawing while the display is closed from two goroutines.
+	frame := image1bit.NewVerticalLSB(d.Bounds())
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for range 20 {
+			d.Draw(d.Bounds(), frame, image.Point{}) //nolint:errcheck
+		}
+	}()
+	for range 2 {
+		go func() {
+			defer wg.Done()
+			if err := d.Close(); err != nil {
+				t.Errorf("Close failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The stream ends once the server is gone.
+	for range lines {
+	}
+	if err := d.Close(); err != nil {
+		t.Errorf("Expected repeated Close to succeed, got %v", err)
+	}
+}
+
+func TestFakeSSD1306_WithRefreshInterval(t *testing.T) {
+	d := NewFakeSSD1306().WithListenAddress("127.0.0.1").WithPort(0).WithRefreshInterval(50 * time.Millisecond)
+	if err := d.Open(); err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer d.Close() //nolint:errcheck
+
+	resp, err := http.Get("http://" + d.Addr() + "/events")
+	if err != nil {
+		t.Fatalf("Failed to connect to event stream: %v", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	lines := streamLines(bufio.NewReader(resp.Body))
+
+	// Skip the initial status and frame.
+	for range 2 {
+		if _, ok := nextEvent(lines, time.Second); !ok {
+			t.Fatal("Expected initial events")
+		}
+	}
+
+	const draws = 100
+	frame := image1bit.NewVerticalLSB(d.Bounds())
+	for i := range draws {
+		frame.SetBit(i, 0, image1bit.On)
+		if err := d.Draw(d.Bounds(), frame, image.Point{}); err != nil {
+			t.Fatalf("Draw failed: %v", err)
+		}
+	}
+
+	frames := 0
+	for {
+		line, ok := nextEvent(lines, 200*time.Millisecond)
+		if !ok {
+			break
+		}
+		if strings.HasPrefix(line, "data: image:") {
+			frames++
+		}
+	}
+
+	if frames == 0 || frames > draws/10 {
+		t.Errorf("Expected a few coalesced frames for %d draws, got %d", draws, frames)
+	}
+}
+
+func TestFakeSSD1306_WaitForViewer_Reconnect(t *testing.T) {
+	d, server := newTestServer(t)
+
+	waitTimesOut := func() bool {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		return errors.Is(d.WaitForViewer(ctx), context.DeadlineExceeded)
+	}
+
+	if !waitTimesOut() {
+		t.Fatal("Expected WaitForViewer to block without a viewer")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/events", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatalf("Failed to connect to event stream: %v", err)
+	}
+	if err := d.WaitForViewer(context.Background()); err != nil {
+		t.Fatalf("Expected WaitForViewer to return once a viewer connected, got %v", err)
+	}
+
+	// Once the viewer leaves, WaitForViewer waits for a new one.
+	cancel()
+	resp.Body.Close() //nolint:errcheck
+	if !waitFor(t, time.Second, func() bool { return d.ClientCount() == 0 }) {
+		t.Fatalf("Expected the viewer to disconnect, got %d clients", d.ClientCount())
+	}
+	if !waitTimesOut() {
+		t.Error("Expected WaitForViewer to block again after the viewer disconnected")
+	}
+
+	connectSSE(t, server)
+	if err := d.WaitForViewer(context.Background()); err != nil {
+		t.Errorf("Expected WaitForViewer to return once a new viewer connected, got %v", err)
+	}
+}
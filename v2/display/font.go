@@ -0,0 +1,210 @@
+package display
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+)
+
+const (
+	DEFAULT_FONT_SIZE float64 = 13
+)
+
+// fontSearchPath returns the directories searched by findFont. It is taken
+// from DISPLAY1306_FONT_PATH, a list separated like PATH, if that is set.
+func fontSearchPath() []string {
+	if path := os.Getenv("DISPLAY1306_FONT_PATH"); path != "" {
+		return filepath.SplitList(path)
+	}
+
+	dirs := []string{"/usr/share/fonts", "/usr/local/share/fonts"}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs,
+			filepath.Join(home, ".fonts"),
+			filepath.Join(home, ".local", "share", "fonts"),
+		)
+	}
+	return dirs
+}
+
+// findFont searches the font search path, including subdirectories, for a
+// .ttf or .otf file whose name without the extension matches name, ignoring
+// case. It returns the path of the first match.
+func findFont(name string) (string, error) {
+	errFound := errors.New("found")
+	var found string
+
+	for _, dir := range fontSearchPath() {
+		err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() {
+				return nil
+			}
+			ext := filepath.Ext(path)
+			if !strings.EqualFold(ext, ".ttf") && !strings.EqualFold(ext, ".otf") {
+				return nil
+			}
+			if strings.EqualFold(strings.TrimSuffix(entry.Name(), ext), name) {
+				found = path
+				return errFound
+			}
+			return nil
+		})
+		if errors.Is(err, errFound) {
+			return found, nil
+		}
+	}
+
+	return "", fmt.Errorf("font %q not found in %s", name, strings.Join(fontSearchPath(), string(os.PathListSeparator)))
+}
+
+// fontFromEnv loads the font named by the DISPLAY1306_FONT environment
+// variable at the size given by DISPLAY1306_FONT_SIZE. It returns a nil face
+// if DISPLAY1306_FONT is not set.
+func fontFromEnv() (font.Face, *truetype.Font, error) {
+	path := os.Getenv("DISPLAY1306_FONT")
+	if path == "" {
+		return nil, nil, nil
+	}
+
+	size := DEFAULT_FONT_SIZE
+	if sizeStr := os.Getenv("DISPLAY1306_FONT_SIZE"); sizeStr != "" {
+		var err error
+		size, err = strconv.ParseFloat(sizeStr, 64)
+		if err != nil || size <= 0 {
+			return nil, nil, fmt.Errorf("invalid DISPLAY1306_FONT_SIZE %q", sizeStr)
+		}
+	}
+
+	tf, err := loadTrueTypeFont(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	face := newTrueTypeFace(tf, size)
+	if err := validateFont(face); err != nil {
+		return nil, nil, err
+	}
+
+	return face, tf, nil
+}
+
+func loadTrueTypeFont(path string) (*truetype.Font, error) {
+	fontData, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read font file: %w", err)
+	}
+
+	tf, err := truetype.Parse(fontData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse font %s: %w", path, err)
+	}
+
+	return tf, nil
+}
+
+func newTrueTypeFace(tf *truetype.Font, size float64) font.Face {
+	return truetype.NewFace(tf, &truetype.Options{
+		Size: size,
+		DPI:  72,
+	})
+}
+
+// PrintLineFit prints text on the given line using the largest size of the
+// active TrueType font, up to maxSize, at which the text fits the width of
+// the display. Other lines keep using the active font. Fonts that cannot be
+// scaled, such as the default basicfont, are used as-is.
+func (d *Display) PrintLineFit(line uint, text string, maxSize float64) error {
+	if maxSize <= 0 {
+		return fmt.Errorf("invalid maximum font size %f", maxSize)
+	}
+
+	return d.batch(func() error {
+		if err := d.PrintLine(line, text); err != nil {
+			return err
+		}
+
+		if d.ttf == nil {
+			return nil
+		}
+
+		size := fitFontSize(d.ttf, text, d.textArea().Dx()-d.gutterWidth(), maxSize)
+		d.lineFaces[int(line)] = newTrueTypeFace(d.ttf, size)
+		return nil
+	})
+}
+
+// FontSizeForLines returns the largest point size, to within a quarter of a
+// point, at which faces of tf have a line height that fits lines rows into
+// the height of bounds. It returns 0 if lines is not positive or no size
+// fits.
+func FontSizeForLines(tf *truetype.Font, bounds image.Rectangle, lines int) float64 {
+	if lines <= 0 {
+		return 0
+	}
+
+	rowHeight := bounds.Dy() / lines
+	fits := func(size float64) bool {
+		return newTrueTypeFace(tf, size).Metrics().Height.Ceil() <= rowHeight
+	}
+
+	if !fits(1) {
+		return 0
+	}
+
+	lo, hi := 1.0, float64(bounds.Dy())
+	for hi-lo > 0.25 {
+		mid := (lo + hi) / 2
+		if fits(mid) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+// fitFontSize returns the largest size, up to maxSize, at which text renders
+// no wider than width pixels.
+func fitFontSize(tf *truetype.Font, text string, width int, maxSize float64) float64 {
+	fits := func(size float64) bool {
+		return font.MeasureString(newTrueTypeFace(tf, size), text).Ceil() <= width
+	}
+
+	if fits(maxSize) {
+		return maxSize
+	}
+
+	lo, hi := 1.0, maxSize
+	for hi-lo > 0.25 {
+		mid := (lo + hi) / 2
+		if fits(mid) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+// replaceMissingGlyphs returns text with every rune that face has no glyph
+// for replaced by the placeholder set with WithMissingGlyphPlaceholder.
+func (d *Display) replaceMissingGlyphs(face font.Face, text string) string {
+	if d.placeholder == 0 {
+		return text
+	}
+
+	return strings.Map(func(r rune) rune {
+		if _, ok := face.GlyphAdvance(r); !ok {
+			return d.placeholder
+		}
+		return r
+	}, text)
+}
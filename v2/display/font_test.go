@@ -0,0 +1,297 @@
+package display
+
+import (
+	"bytes"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/math/fixed"
+	"periph.io/x/devices/v3/ssd1306/image1bit"
+)
+
+// writeTestFont writes a TrueType font to a temporary file and returns its
+// path.
+func writeTestFont(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "goregular.ttf")
+	if err := os.WriteFile(path, goregular.TTF, 0o644); err != nil {
+		t.Fatalf("Failed to write font: %v", err)
+	}
+	return path
+}
+
+func TestDisplay_Build_ExplicitFontOverridesEnv(t *testing.T) {
+	t.Setenv("DISPLAY1306_FONT", writeTestFont(t))
+	t.Setenv("DISPLAY1306_FONT_SIZE", "24")
+
+	display, err := NewDisplay().WithFont(basicfont.Face7x13).Build()
+	assertNoError(t, err)
+
+	if display.font != basicfont.Face7x13 {
+		t.Error("Expected explicit font to take precedence over the environment")
+	}
+}
+
+func TestFitFontSize(t *testing.T) {
+	tf, err := truetype.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatalf("Failed to parse font: %v", err)
+	}
+
+	short := fitFontSize(tf, "42", 128, 64)
+	long := fitFontSize(tf, "a considerably longer string", 128, 64)
+
+	if short <= long {
+		t.Errorf("Expected short text to get a larger size than long text, got %.2f <= %.2f", short, long)
+	}
+	if short != 64 {
+		t.Errorf("Expected short text to be capped at the maximum size, got %.2f", short)
+	}
+	if width := font.MeasureString(newTrueTypeFace(tf, long), "a considerably longer string").Ceil(); width > 128 {
+		t.Errorf("Expected fitted text to be at most 128 pixels wide, got %d", width)
+	}
+}
+
+func TestFontSizeForLines(t *testing.T) {
+	tf, err := truetype.Parse(goregular.TTF)
+	assertNoError(t, err)
+
+	bounds := image.Rect(0, 0, 128, 64)
+	for _, lines := range []int{1, 2, 4, 5, 8} {
+		size := FontSizeForLines(tf, bounds, lines)
+		height := newTrueTypeFace(tf, size).Metrics().Height.Ceil()
+		rowHeight := bounds.Dy() / lines
+		if height > rowHeight || height < rowHeight-1 {
+			t.Errorf("%d lines: expected line height of about %d, got %d at size %.2f", lines, rowHeight, height, size)
+		}
+	}
+
+	if size := FontSizeForLines(tf, bounds, 0); size != 0 {
+		t.Errorf("Expected 0 for no lines, got %f", size)
+	}
+}
+
+func TestDisplay_PrintLineFit(t *testing.T) {
+	tf, err := truetype.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatalf("Failed to parse font: %v", err)
+	}
+
+	display, mock := newTestDisplay(t, newTestBuilder().WithTrueTypeFont(tf, 10))
+	activeFont := display.font
+
+	assertNoError(t, display.PrintLineFit(0, "21.5C", 40))
+
+	if display.buffer[0] != "21.5C" {
+		t.Errorf("Expected buffer[0] to be %q, got %q", "21.5C", display.buffer[0])
+	}
+	if display.font != activeFont {
+		t.Error("Expected active font to be restored")
+	}
+
+	fitted, ok := display.lineFaces[0]
+	if !ok {
+		t.Fatal("Expected line 0 to use a fitted face")
+	}
+	if fitted.Metrics().Height <= activeFont.Metrics().Height {
+		t.Error("Expected fitted face to be larger than the active font")
+	}
+
+	assertNoError(t, display.Update())
+	assertMethodCalled(t, mock, "Draw")
+
+	// Printing regular text on the line drops the fitted face.
+	assertNoError(t, display.PrintLine(0, "plain"))
+	if _, ok := display.lineFaces[0]; ok {
+		t.Error("Expected PrintLine to reset the fitted face")
+	}
+}
+
+func TestDisplay_PrintLineFit_Placement(t *testing.T) {
+	tf, err := truetype.Parse(goregular.TTF)
+	assertNoError(t, err)
+
+	const text = "21"
+	display, mock := newTestDisplay(t, newTestBuilder().WithTrueTypeFont(tf, 10).WithLines(2))
+	assertNoError(t, display.PrintLineFit(1, text, 40))
+	assertNoError(t, display.Update())
+
+	_, src, _ := mock.LastDrawArgs()
+	extent := litExtent(src)
+	if top := display.lineRect(1).Min.Y; extent.Min.Y < top {
+		t.Errorf("Expected the fitted text to start at or below the top of line 1 (y=%d), got y=%d", top, extent.Min.Y)
+	}
+	if extent.Dy() <= display.lineHeight {
+		t.Errorf("Expected the fitted text to be taller than a line (%d), got %d", display.lineHeight, extent.Dy())
+	}
+
+	// The fitted text must light as many pixels as it does when drawn with
+	// plenty of room, so nothing was clipped.
+	reference := image1bit.NewVerticalLSB(image.Rect(0, 0, 128, 200))
+	drawer := font.Drawer{
+		Dst:  reference,
+		Src:  &image.Uniform{image1bit.On},
+		Face: display.lineFaces[1],
+		Dot:  fixed.P(0, 100),
+	}
+	drawer.DrawString(text)
+	if got, want := countOn(src, src.Bounds()), countOn(reference, reference.Bounds()); got != want {
+		t.Errorf("Expected %d lit pixels for unclipped text, got %d", want, got)
+	}
+}
+
+func TestDisplay_PrintLineFit_BasicFont(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder())
+
+	assertNoError(t, display.PrintLineFit(0, "42", 40))
+
+	if display.buffer[0] != "42" {
+		t.Errorf("Expected buffer[0] to be %q, got %q", "42", display.buffer[0])
+	}
+	if len(display.lineFaces) != 0 {
+		t.Error("Expected basicfont text to be rendered as-is")
+	}
+}
+
+func TestDisplay_PrintLineFit_InvalidSize(t *testing.T) {
+	tf, err := truetype.Parse(goregular.TTF)
+	assertNoError(t, err)
+
+	for name, builder := range map[string]*Display{
+		"truetype":  newTestBuilder().WithTrueTypeFont(tf, 10),
+		"basicfont": newTestBuilder(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			display, _ := newTestDisplay(t, builder)
+			assertNoError(t, display.PrintLine(0, "old"))
+
+			err := display.PrintLineFit(0, "new", 0)
+			assertError(t, err, "invalid maximum font size")
+			if display.buffer[0] != "old" {
+				t.Errorf("Expected an invalid call to leave the line alone, got %q", display.buffer[0])
+			}
+		})
+	}
+}
+
+func TestDisplay_Update_LargeFontFitsAtTop(t *testing.T) {
+	tf, err := truetype.Parse(goregular.TTF)
+	assertNoError(t, err)
+
+	const text = "ÅÉ"
+	display, mock := newTestDisplay(t, newTestBuilder().WithTrueTypeFont(tf, 40).WithLines(1))
+	assertNoError(t, display.PrintLine(0, text))
+	assertNoError(t, display.Update())
+
+	bounds, _ := font.BoundString(display.font, text)
+	if top := display.baseline(0) + bounds.Min.Y.Floor(); top < 0 {
+		t.Errorf("Expected the top of line 0 to be on the display, got y=%d", top)
+	}
+
+	// Drawing the same text with plenty of headroom must light exactly the
+	// same number of pixels, so nothing was clipped at the top.
+	reference := image1bit.NewVerticalLSB(image.Rect(0, 0, 128, 200))
+	drawer := font.Drawer{
+		Dst:  reference,
+		Src:  &image.Uniform{image1bit.On},
+		Face: display.font,
+		Dot:  fixed.P(0, 100),
+	}
+	drawer.DrawString(text)
+
+	_, src, _ := mock.LastDrawArgs()
+	if got, want := countOn(src, src.Bounds()), countOn(reference, reference.Bounds()); got != want {
+		t.Errorf("Expected %d lit pixels for unclipped text, got %d", want, got)
+	}
+}
+
+func TestDisplay_WithFontName(t *testing.T) {
+	dir := t.TempDir()
+	nested := filepath.Join(dir, "truetype", "go")
+	assertNoError(t, os.MkdirAll(nested, 0o755))
+	assertNoError(t, os.WriteFile(filepath.Join(nested, "GoRegular.ttf"), goregular.TTF, 0o644))
+	t.Setenv("DISPLAY1306_FONT_PATH", filepath.Join(dir, "missing")+string(os.PathListSeparator)+dir)
+
+	path, err := findFont("goregular")
+	assertNoError(t, err)
+	if filepath.Base(path) != "GoRegular.ttf" {
+		t.Errorf("Expected to find GoRegular.ttf, got %s", path)
+	}
+
+	display, err := newTestBuilder().WithDriver(NewTrackedFakeSSD1306()).WithFontName("GoRegular", 20).Build()
+	assertNoError(t, err)
+	if display.ttf == nil || display.lineHeight != 20 {
+		t.Errorf("Expected the named font at size 20, got line height %d", display.lineHeight)
+	}
+
+	_, err = newTestBuilder().WithFontName("NoSuchFont", 20).Build()
+	assertError(t, err, `font "NoSuchFont" not found`)
+}
+
+func TestDisplay_WithMissingGlyphPlaceholder(t *testing.T) {
+	render := func(builder *Display, text string) []byte {
+		display, mock := newTestDisplay(t, builder.WithFont(basicfont.Face7x13))
+		assertNoError(t, display.PrintLine(0, text))
+		assertNoError(t, display.Update())
+		_, src, _ := mock.LastDrawArgs()
+		return src.(*image1bit.VerticalLSB).Pix
+	}
+
+	want := render(newTestBuilder(), "A#B")
+	if got := render(newTestBuilder().WithMissingGlyphPlaceholder('#'), "A\u4e2dB"); !bytes.Equal(got, want) {
+		t.Error("Expected the missing glyph to be drawn as the placeholder")
+	}
+	if got := render(newTestBuilder(), "A\u4e2dB"); bytes.Equal(got, want) {
+		t.Error("Expected no placeholder by default")
+	}
+}
+
+func TestDisplay_TextBounds(t *testing.T) {
+	tf, err := truetype.Parse(goregular.TTF)
+	assertNoError(t, err)
+	display, _ := newTestDisplay(t, newTestBuilder().WithTrueTypeFont(tf, 16))
+
+	caps := display.TextBounds("HELLO")
+	descenders := display.TextBounds("gjpqy")
+	if descenders.Dy() <= caps.Dy() {
+		t.Errorf("Expected %v with descenders to be taller than %v", descenders, caps)
+	}
+	if descenders.Max.Y <= caps.Max.Y {
+		t.Errorf("Expected %v to extend further below the baseline than %v", descenders, caps)
+	}
+	if caps.Min.Y >= 0 || caps.Dx() <= 0 {
+		t.Errorf("Expected capitals to extend above the baseline, got %v", caps)
+	}
+}
+
+func TestDisplay_PrintAt_ProportionalFont(t *testing.T) {
+	tf, err := truetype.Parse(goregular.TTF)
+	assertNoError(t, err)
+	display, _ := newTestDisplay(t, newTestBuilder().WithTrueTypeFont(tf, 12))
+
+	// Narrow characters take up less than a cell each, so column 2 falls
+	// several characters into the line.
+	assertNoError(t, display.PrintLine(0, "iiiiiiiiiiii"))
+	assertNoError(t, display.PrintAt(0, 2, "X"))
+
+	x := 2 * display.cellWidth()
+	prefix, _, found := strings.Cut(display.buffer[0], "X")
+	if !found {
+		t.Fatalf("Expected X in the line, got %q", display.buffer[0])
+	}
+	if w := display.textWidth(prefix); w < x || display.textWidth(prefix[:len(prefix)-1]) >= x {
+		t.Errorf("Expected X to start at the first character at or after x=%d, got it after %q (%d pixels)", x, prefix, w)
+	}
+
+	assertNoError(t, display.EraseToEndOfLine(0, 2))
+	if display.buffer[0] != prefix {
+		t.Errorf("Expected erasing from column 2 to leave %q, got %q", prefix, display.buffer[0])
+	}
+}
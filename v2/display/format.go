@@ -0,0 +1,105 @@
+package display
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// PrintNumber prints value on the given line in compact form, such as "1.2k"
+// for 1234 with one decimal place.
+func (d *Display) PrintNumber(line uint, value float64, decimals int) error {
+	return d.PrintLine(line, compactNumber(value, decimals))
+}
+
+// PrintDuration prints a duration on the given line in compact form, such as
+// "3m20s".
+func (d *Display) PrintDuration(line uint, duration time.Duration) error {
+	return d.PrintLine(line, compactDuration(duration))
+}
+
+// PrintField prints value on the given line padded with spaces to width
+// characters, aligned within the field according to align, so that columns
+// of changing values stay put with a monospaced font. The padding is removed
+// again if WithTrimLines or WithTrimLeadingSpace is in effect.
+func (d *Display) PrintField(line uint, width int, value string, align HAlign) error {
+	field, err := padField(value, width, align)
+	if err != nil {
+		return err
+	}
+	return d.PrintLine(line, field)
+}
+
+// padField pads value with spaces to width runes.
+func padField(value string, width int, align HAlign) (string, error) {
+	if width <= 0 {
+		return "", fmt.Errorf("invalid field width %d", width)
+	}
+
+	pad := width - utf8.RuneCountInString(value)
+	if pad < 0 {
+		return "", fmt.Errorf("value %q does not fit in a field of width %d", value, width)
+	}
+
+	var left int
+	switch align {
+	case AlignCenter:
+		left = pad / 2
+	case AlignRight:
+		left = pad
+	}
+	return strings.Repeat(" ", left) + value + strings.Repeat(" ", pad-left), nil
+}
+
+// compactNumber formats value with the given number of decimal places,
+// scaling values of a thousand or more down with a k, M, G or T suffix. The
+// suffix is chosen after rounding, so 999999 with no decimal places is "1M"
+// rather than "1000k".
+func compactNumber(value float64, decimals int) string {
+	suffixes := []string{"", "k", "M", "G", "T"}
+	decimals = max(decimals, 0)
+	scale := math.Pow10(decimals)
+	rounded := func(v float64) float64 {
+		return math.Round(v*scale) / scale
+	}
+
+	i := 0
+	for math.Abs(rounded(value)) >= 1000 && i < len(suffixes)-1 {
+		value /= 1000
+		i++
+	}
+	return strconv.FormatFloat(value, 'f', decimals, 64) + suffixes[i]
+}
+
+// compactDuration formats a duration using at most two units, such as
+// "250ms", "4.5s", "3m20s", "2h5m" or "3d4h".
+func compactDuration(duration time.Duration) string {
+	if duration < 0 {
+		return "-" + compactDuration(-duration)
+	}
+
+	// Round to the precision shown before choosing the units, so that
+	// 59.96s is "1m0s" rather than "60s".
+	if duration >= time.Second && duration < time.Minute {
+		duration = duration.Round(100 * time.Millisecond)
+	}
+
+	const day = 24 * time.Hour
+	switch {
+	case duration < time.Millisecond:
+		return duration.String()
+	case duration < time.Second:
+		return fmt.Sprintf("%dms", duration.Milliseconds())
+	case duration < time.Minute:
+		return strconv.FormatFloat(duration.Seconds(), 'f', -1, 64) + "s"
+	case duration < time.Hour:
+		return fmt.Sprintf("%dm%ds", duration/time.Minute, duration%time.Minute/time.Second)
+	case duration < day:
+		return fmt.Sprintf("%dh%dm", duration/time.Hour, duration%time.Hour/time.Minute)
+	default:
+		return fmt.Sprintf("%dd%dh", duration/day, duration%day/time.Hour)
+	}
+}
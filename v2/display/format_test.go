@@ -0,0 +1,89 @@
+package display
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCompactNumber(t *testing.T) {
+	tests := []struct {
+		value    float64
+		decimals int
+		want     string
+	}{
+		{42, 0, "42"},
+		{3.14159, 2, "3.14"},
+		{999, 0, "999"},
+		{1234, 1, "1.2k"},
+		{-56789, 1, "-56.8k"},
+		{2500000, 1, "2.5M"},
+		{7e9, 0, "7G"},
+		{1e18, 0, "1000000T"},
+		{999999, 0, "1M"},
+		{999.95, 1, "1.0k"},
+		{999.94, 1, "999.9"},
+		{-999999, 0, "-1M"},
+	}
+
+	for _, tt := range tests {
+		if got := compactNumber(tt.value, tt.decimals); got != tt.want {
+			t.Errorf("compactNumber(%v, %d): expected %q, got %q", tt.value, tt.decimals, tt.want, got)
+		}
+	}
+}
+
+func TestCompactDuration(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		want     string
+	}{
+		{500 * time.Microsecond, "500µs"},
+		{250 * time.Millisecond, "250ms"},
+		{4500 * time.Millisecond, "4.5s"},
+		{42 * time.Second, "42s"},
+		{200 * time.Second, "3m20s"},
+		{2*time.Hour + 5*time.Minute + 9*time.Second, "2h5m"},
+		{76 * time.Hour, "3d4h"},
+		{-90 * time.Second, "-1m30s"},
+		{59960 * time.Millisecond, "1m0s"},
+		{59940 * time.Millisecond, "59.9s"},
+	}
+
+	for _, tt := range tests {
+		if got := compactDuration(tt.duration); got != tt.want {
+			t.Errorf("compactDuration(%v): expected %q, got %q", tt.duration, tt.want, got)
+		}
+	}
+}
+
+func TestDisplay_PrintNumber(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder())
+
+	assertNoError(t, display.PrintNumber(0, 1234, 1))
+	assertNoError(t, display.PrintDuration(1, 200*time.Second))
+	if display.buffer[0] != "1.2k" || display.buffer[1] != "3m20s" {
+		t.Errorf("Expected compact values, got %q", display.buffer[:2])
+	}
+}
+
+func TestDisplay_PrintField(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder())
+
+	assertNoError(t, display.PrintField(0, 5, "123", AlignRight))
+	assertNoError(t, display.PrintField(1, 5, "7", AlignRight))
+	if display.buffer[0] != "  123" || display.buffer[1] != "    7" {
+		t.Errorf("Expected right-aligned fields, got %q", display.buffer[:2])
+	}
+	if len(display.buffer[0]) != len(display.buffer[1]) {
+		t.Errorf("Expected both fields to have the same width, got %q", display.buffer[:2])
+	}
+
+	assertNoError(t, display.PrintField(2, 5, "ab", AlignLeft))
+	assertNoError(t, display.PrintField(3, 5, "ab", AlignCenter))
+	if display.buffer[2] != "ab   " || display.buffer[3] != " ab  " {
+		t.Errorf("Expected left and centered fields, got %q", display.buffer[2:4])
+	}
+
+	assertError(t, display.PrintField(4, 2, "123", AlignRight), "does not fit")
+	assertError(t, display.PrintField(4, 0, "", AlignRight), "invalid field width")
+}
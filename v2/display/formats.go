@@ -0,0 +1,19 @@
+package display
+
+import (
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/webp"
+)
+
+// SupportedImageFormats returns the names of the image formats that
+// ShowImageFromFile and ShowImageFromReader can decode. The image package
+// offers no way to list registered decoders, so this is the list of formats
+// this package registers; formats registered elsewhere in a program are also
+// decoded but are not listed.
+func SupportedImageFormats() []string {
+	return []string{"bmp", "gif", "jpeg", "png", "webp"}
+}
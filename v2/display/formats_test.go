@@ -0,0 +1,38 @@
+package display
+
+import (
+	"encoding/base64"
+	"image"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// testWebP is a lossless 1x1 WebP image.
+const testWebP = "UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA=="
+
+func TestSupportedImageFormats(t *testing.T) {
+	formats := SupportedImageFormats()
+	for _, name := range []string{"png", "jpeg", "gif", "bmp", "webp"} {
+		if !slices.Contains(formats, name) {
+			t.Errorf("Expected %q in supported formats %v", name, formats)
+		}
+	}
+}
+
+func TestDisplay_ShowImageFromReader_WebP(t *testing.T) {
+	data, err := base64.StdEncoding.DecodeString(testWebP)
+	assertNoError(t, err)
+
+	_, format, err := image.DecodeConfig(strings.NewReader(string(data)))
+	assertNoError(t, err)
+	if format != "webp" {
+		t.Fatalf("Expected webp format, got %q", format)
+	}
+
+	display, mock := newTestDisplay(t, newTestBuilder())
+	assertNoError(t, display.ShowImageFromReader(strings.NewReader(string(data))))
+	if got := mock.CallCount("Draw"); got != 1 {
+		t.Errorf("Expected the WebP image to be drawn, got %d draws", got)
+	}
+}
@@ -0,0 +1,141 @@
+package display
+
+import (
+	"image"
+	"strings"
+
+	"periph.io/x/devices/v3/ssd1306/image1bit"
+)
+
+var (
+	batteryOutline = []string{
+		"############..",
+		"#..........#..",
+		"#..........###",
+		"#..........###",
+		"#..........###",
+		"#..........#..",
+		"############..",
+	}
+
+	// wifiLayers holds the dot and the three arcs of the wifi icon, from
+	// the innermost out. Level n shows the dot and the first n arcs.
+	wifiLayers = [][]string{
+		{
+			"...........",
+			"...........",
+			"...........",
+			"...........",
+			"...........",
+			"...........",
+			"...........",
+			"...........",
+			".....#.....",
+		},
+		{
+			"...........",
+			"...........",
+			"...........",
+			"...........",
+			"...........",
+			"....###....",
+			"...#...#...",
+			"...........",
+			"...........",
+		},
+		{
+			"...........",
+			"...........",
+			"...........",
+			"...#####...",
+			"..#.....#..",
+			"...........",
+			"...........",
+			"...........",
+			"...........",
+		},
+		{
+			"..#######..",
+			".#.......#.",
+			"#.........#",
+			"...........",
+			"...........",
+			"...........",
+			"...........",
+			"...........",
+			"...........",
+		},
+	}
+
+	bluetoothIcon = []string{
+		"...#...",
+		"...##..",
+		".#.#.#.",
+		"..###..",
+		"...#...",
+		"..###..",
+		".#.#.#.",
+		"...##..",
+		"...#...",
+	}
+
+	arrowUpIcon = []string{
+		"...#...",
+		"..###..",
+		".#####.",
+		"#######",
+		"..###..",
+		"..###..",
+		"..###..",
+	}
+)
+
+// BuiltinIcon returns one of a small set of 1-bit status icons, or nil if
+// name is not known. The level selects a variant of icons that have them and
+// is clamped to the valid range:
+//
+//   - "battery": charge level from 0 (empty) to 4 (full)
+//   - "wifi": signal strength from 0 to 3 arcs
+//   - "bluetooth", "arrow-up", "arrow-down": level is ignored
+func BuiltinIcon(name string, level int) image.Image {
+	switch name {
+	case "battery":
+		icon := parseIcon(batteryOutline)
+		level = min(max(level, 0), 4)
+		fillRect(icon, image.Rect(2, 2, 2+2*level, 5), image1bit.On)
+		return icon
+	case "wifi":
+		level = min(max(level, 0), len(wifiLayers)-1)
+		icon := parseIcon(wifiLayers[0])
+		for _, layer := range wifiLayers[1 : level+1] {
+			overlay := parseIcon(layer)
+			for i, b := range overlay.Pix {
+				icon.Pix[i] |= b
+			}
+		}
+		return icon
+	case "bluetooth":
+		return parseIcon(bluetoothIcon)
+	case "arrow-up":
+		return parseIcon(arrowUpIcon)
+	case "arrow-down":
+		rows := make([]string, len(arrowUpIcon))
+		for i, row := range arrowUpIcon {
+			rows[len(rows)-1-i] = row
+		}
+		return parseIcon(rows)
+	}
+	return nil
+}
+
+// parseIcon converts rows of '#' (lit) and '.' (dark) characters into a
+// 1-bit image.
+func parseIcon(rows []string) *image1bit.VerticalLSB {
+	icon := image1bit.NewVerticalLSB(image.Rect(0, 0, len(rows[0]), len(rows)))
+	for y, row := range rows {
+		for x, c := range strings.Split(row, "") {
+			icon.SetBit(x, y, c == "#")
+		}
+	}
+	return icon
+}
@@ -0,0 +1,56 @@
+package display
+
+import (
+	"image"
+	"testing"
+
+	"periph.io/x/devices/v3/ssd1306/image1bit"
+)
+
+func TestBuiltinIcon(t *testing.T) {
+	battery := BuiltinIcon("battery", 3)
+	if battery == nil {
+		t.Fatal("Expected a battery icon")
+	}
+	if got := battery.Bounds(); got != image.Rect(0, 0, 14, 7) {
+		t.Errorf("Expected a 14x7 battery icon, got %v", got)
+	}
+
+	empty := countOn(BuiltinIcon("battery", 0), battery.Bounds())
+	if got := countOn(battery, battery.Bounds()) - empty; got != 3*2*3 {
+		t.Errorf("Expected level 3 to fill 18 pixels, got %d", got)
+	}
+	if countOn(BuiltinIcon("battery", 9), battery.Bounds()) != countOn(BuiltinIcon("battery", 4), battery.Bounds()) {
+		t.Error("Expected levels above 4 to be clamped")
+	}
+
+	wifi := BuiltinIcon("wifi", 1).Bounds()
+	if countOn(BuiltinIcon("wifi", 3), wifi) <= countOn(BuiltinIcon("wifi", 1), wifi) {
+		t.Error("Expected a stronger wifi signal to light more pixels")
+	}
+
+	for _, name := range []string{"bluetooth", "arrow-up", "arrow-down"} {
+		if BuiltinIcon(name, 0) == nil {
+			t.Errorf("Expected a %s icon", name)
+		}
+	}
+
+	if BuiltinIcon("teapot", 0) != nil {
+		t.Error("Expected nil for an unknown icon")
+	}
+}
+
+func TestDisplay_DrawImageAt(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder())
+
+	icon := BuiltinIcon("arrow-up", 0)
+	assertNoError(t, display.DrawImageAt(icon, image.Pt(120, 60)))
+
+	// The icon is clipped at the edges of the display.
+	if display.fb.BitAt(123, 60) != image1bit.On {
+		t.Error("Expected the tip of the arrow at (123,60)")
+	}
+	if got := countOn(display.fb, image.Rect(0, 0, 120, 64)); got != 0 {
+		t.Errorf("Expected nothing drawn left of the icon, got %d pixels", got)
+	}
+}
@@ -0,0 +1,183 @@
+package display
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"periph.io/x/devices/v3/ssd1306/image1bit"
+)
+
+// luma returns the luminance of a color.
+func luma(c color.Color) uint8 {
+	return color.GrayModel.Convert(c).(color.Gray).Y
+}
+
+// threshold converts a luminance value to a 1-bit pixel.
+func threshold(y uint8) image1bit.Bit {
+	return image1bit.Bit(y > 128)
+}
+
+// gammaTable returns a lookup table applying gamma correction g to
+// luminance values.
+func gammaTable(g float64) [256]uint8 {
+	var table [256]uint8
+	for i := range table {
+		table[i] = uint8(math.Round(255 * math.Pow(float64(i)/255, g)))
+	}
+	return table
+}
+
+// convertImage converts the part of img that fits on the display to a 1-bit
+// frame, with the top left corner of img at the top left of the display.
+func (d *Display) convertImage(img image.Image) *image1bit.VerticalLSB {
+	bounds := d.driver.Bounds()
+	displayImg := image1bit.NewVerticalLSB(bounds)
+	d.convertInto(displayImg, img, bounds.Min, bounds)
+	return displayImg
+}
+
+// ConvertImage converts img to a 1-bit frame exactly as ShowImage would,
+// without drawing it. The frame's Pix holds the page-packed bytes that
+// ShowRaw accepts, so images can be converted ahead of time, for instance
+// in another goroutine, and shown later.
+func (d *Display) ConvertImage(img image.Image) (*image1bit.VerticalLSB, error) {
+	if !d.initialized {
+		return nil, fmt.Errorf("driver has not been initialized")
+	}
+
+	return d.convertImage(img), nil
+}
+
+// convertInto converts img to 1-bit and writes it into dst with the top left
+// corner of img at the given point. Parts of img outside of dst or clip are
+// ignored.
+func (d *Display) convertInto(dst *image1bit.VerticalLSB, img image.Image, at image.Point, clip image.Rectangle) {
+	imgBounds := img.Bounds()
+	area := imgBounds.Sub(imgBounds.Min).Add(at).Intersect(dst.Bounds()).Intersect(clip)
+	offset := imgBounds.Min.Sub(at)
+
+	width, height := area.Dx(), area.Dy()
+	levels := make([]uint8, width*height)
+	var lo, hi uint8 = 255, 0
+	for y := range height {
+		for x := range width {
+			v := luma(img.At(area.Min.X+x+offset.X, area.Min.Y+y+offset.Y))
+			levels[y*width+x] = v
+			lo = min(lo, v)
+			hi = max(hi, v)
+		}
+	}
+
+	table := gammaTable(d.gamma)
+	if d.autoContrast {
+		stretch := contrastTable(lo, hi)
+		for i := range stretch {
+			stretch[i] = table[stretch[i]]
+		}
+		table = stretch
+	}
+
+	for y := range height {
+		for x := range width {
+			dst.SetBit(area.Min.X+x, area.Min.Y+y, threshold(table[levels[y*width+x]]))
+		}
+	}
+}
+
+// DrawImageAt converts img to 1-bit and draws it into the framebuffer with
+// its top left corner at the given point.
+func (d *Display) DrawImageAt(img image.Image, at image.Point) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	d.convertInto(d.fb, img, at, d.clipRect())
+	return d.changed()
+}
+
+// Blit draws img into the framebuffer with its top left corner at the given
+// point, like DrawImageAt, except that fully transparent pixels are skipped
+// and leave the framebuffer as it was. This allows icons and sprites with a
+// transparent background to be overlaid on existing content.
+func (d *Display) Blit(img image.Image, at image.Point) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	table := gammaTable(d.gamma)
+	b := img.Bounds()
+	offset := at.Sub(b.Min)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := img.At(x, y)
+			if _, _, _, a := c.RGBA(); a == 0 {
+				continue
+			}
+			d.setPixel(x+offset.X, y+offset.Y, threshold(table[luma(c)]))
+		}
+	}
+	return d.changed()
+}
+
+// DrawImageCentered converts img to 1-bit and draws it into the framebuffer,
+// centered on the display. Images larger than the display are cropped
+// equally on each side.
+func (d *Display) DrawImageCentered(img image.Image) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	bounds := d.driver.Bounds()
+	size := img.Bounds().Size()
+	at := bounds.Min.Add(bounds.Size().Sub(size).Div(2))
+	d.convertInto(d.fb, img, at, d.clipRect())
+	return d.changed()
+}
+
+// contrastTable returns a lookup table that linearly stretches luminance
+// values in the range [lo, hi] to [0, 255]. If the range is empty the table
+// is the identity.
+func contrastTable(lo, hi uint8) [256]uint8 {
+	var table [256]uint8
+	for i := range table {
+		switch {
+		case hi <= lo:
+			table[i] = uint8(i)
+		case i <= int(lo):
+			table[i] = 0
+		case i >= int(hi):
+			table[i] = 255
+		default:
+			table[i] = uint8((i - int(lo)) * 255 / (int(hi) - int(lo)))
+		}
+	}
+	return table
+}
+
+// DrawBitmapScaled draws img into the framebuffer with its top left corner at
+// the given point, enlarged by an integer scale factor. Each lit pixel of img
+// becomes a scale x scale block set to on; unlit pixels leave the framebuffer
+// unchanged.
+func (d *Display) DrawBitmapScaled(img image.Image, at image.Point, scale int, on bool) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	if scale <= 0 {
+		return fmt.Errorf("invalid scale %d", scale)
+	}
+
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if !isLit(img.At(x, y)) {
+				continue
+			}
+			origin := at.Add(image.Pt(x-b.Min.X, y-b.Min.Y).Mul(scale))
+			d.fillFramebuffer(image.Rectangle{Min: origin, Max: origin.Add(image.Pt(scale, scale))}, image1bit.Bit(on))
+		}
+	}
+	return d.changed()
+}
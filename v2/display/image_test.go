@@ -0,0 +1,348 @@
+package display
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"periph.io/x/devices/v3/ssd1306/image1bit"
+)
+
+// newFilledImage returns a test image in which every pixel has the given
+// gray level.
+func newFilledImage(width, height int, level uint8) *TestImage {
+	img := NewTestImage(width, height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.Gray{Y: level})
+		}
+	}
+	return img
+}
+
+// writePNG encodes img as a PNG file in a temporary directory and returns its
+// path.
+func writePNG(t *testing.T, img image.Image) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "image.png")
+	f, err := os.Create(path)
+	assertNoError(t, err)
+	defer f.Close() //nolint:errcheck
+	assertNoError(t, png.Encode(f, img))
+	return path
+}
+
+func TestGammaTable(t *testing.T) {
+	identity := gammaTable(1)
+	for i, v := range identity {
+		if int(v) != i {
+			t.Fatalf("Expected gamma 1 to be the identity, got %d for %d", v, i)
+		}
+	}
+
+	bright := gammaTable(0.5)
+	dark := gammaTable(2)
+	if bright[0] != 0 || bright[255] != 255 || dark[0] != 0 || dark[255] != 255 {
+		t.Error("Expected gamma correction to preserve black and white")
+	}
+	if bright[64] <= 64 || dark[192] >= 192 {
+		t.Error("Expected gamma below 1 to brighten and above 1 to darken midtones")
+	}
+}
+
+func TestDisplay_WithGamma(t *testing.T) {
+	litPixels := func(builder *Display) int {
+		display, mock := newTestDisplay(t, builder)
+		assertNoError(t, display.ShowImage(newFilledImage(128, 64, 60)))
+		_, src, _ := mock.LastDrawArgs()
+		return countOn(src, src.Bounds())
+	}
+
+	normal := litPixels(newTestBuilder())
+	brightened := litPixels(newTestBuilder().WithGamma(0.4))
+
+	if brightened <= normal {
+		t.Errorf("Expected gamma < 1 to light more pixels than gamma 1, got %d <= %d", brightened, normal)
+	}
+
+	_, err := newTestBuilder().WithGamma(0).Build()
+	assertError(t, err, "invalid gamma")
+}
+
+func TestContrastTable(t *testing.T) {
+	table := contrastTable(100, 140)
+	if table[100] != 0 || table[140] != 255 || table[120] != 127 {
+		t.Errorf("Expected 100-140 to stretch to 0-255, got %d, %d, %d", table[100], table[120], table[140])
+	}
+
+	flat := contrastTable(80, 80)
+	for i, v := range flat {
+		if int(v) != i {
+			t.Fatalf("Expected degenerate range to give the identity, got %d for %d", v, i)
+		}
+	}
+}
+
+func TestDisplay_WithAutoContrast(t *testing.T) {
+	// A horizontal gradient from 90 to 120, entirely below the threshold.
+	gradient := NewTestImage(128, 64)
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 128; x++ {
+			gradient.Set(x, y, color.Gray{Y: uint8(90 + x*30/127)})
+		}
+	}
+
+	litPixels := func(builder *Display, img *TestImage) int {
+		display, mock := newTestDisplay(t, builder)
+		assertNoError(t, display.ShowImage(img))
+		_, src, _ := mock.LastDrawArgs()
+		return countOn(src, src.Bounds())
+	}
+
+	if got := litPixels(newTestBuilder(), gradient); got != 0 {
+		t.Fatalf("Expected low-contrast gradient to be all black without stretching, got %d lit", got)
+	}
+
+	total := 128 * 64
+	stretched := litPixels(newTestBuilder().WithAutoContrast(true), gradient)
+	if stretched < total/3 || stretched > total*2/3 {
+		t.Errorf("Expected roughly half of %d pixels lit after stretching, got %d", total, stretched)
+	}
+
+	// A flat image must not divide by zero and is left as-is.
+	if got := litPixels(newTestBuilder().WithAutoContrast(true), newFilledImage(128, 64, 200)); got != total {
+		t.Errorf("Expected flat bright image to be fully lit, got %d", got)
+	}
+}
+
+func TestDisplay_WithImageCache(t *testing.T) {
+	decodes := 0
+	origDecode := decodeImage
+	decodeImage = func(r io.Reader) (image.Image, string, error) {
+		decodes++
+		return origDecode(r)
+	}
+	t.Cleanup(func() { decodeImage = origDecode })
+
+	path := writePNG(t, newFilledImage(16, 16, 255))
+
+	display, mock := newTestDisplay(t, newTestBuilder().WithImageCache(true))
+	assertNoError(t, display.ShowImageFromFile(path))
+	assertNoError(t, display.ShowImageFromFile(path))
+	if decodes != 1 {
+		t.Errorf("Expected 1 decode for a repeated file, got %d", decodes)
+	}
+	if got := mock.CallCount("Draw"); got != 2 {
+		t.Errorf("Expected 2 draws, got %d", got)
+	}
+
+	// A new modification time invalidates the cached frame.
+	later := time.Now().Add(time.Hour)
+	assertNoError(t, os.Chtimes(path, later, later))
+	assertNoError(t, display.ShowImageFromFile(path))
+	if decodes != 2 {
+		t.Errorf("Expected modified file to be decoded again, got %d decodes", decodes)
+	}
+
+	// Without the cache every call decodes.
+	uncached, _ := newTestDisplay(t, newTestBuilder())
+	assertNoError(t, uncached.ShowImageFromFile(path))
+	assertNoError(t, uncached.ShowImageFromFile(path))
+	if decodes != 4 {
+		t.Errorf("Expected 4 decodes without caching, got %d", decodes)
+	}
+}
+
+func TestDisplay_ImageLimits(t *testing.T) {
+	decodes := 0
+	origDecode := decodeImage
+	decodeImage = func(r io.Reader) (image.Image, string, error) {
+		decodes++
+		return origDecode(r)
+	}
+	t.Cleanup(func() { decodeImage = origDecode })
+
+	path := writePNG(t, newFilledImage(256, 128, 255))
+	info, err := os.Stat(path)
+	assertNoError(t, err)
+
+	display, _ := newTestDisplay(t, newTestBuilder().WithMaxImageDimensions(128, 64))
+	assertError(t, display.ShowImageFromFile(path), "image of 256x128 pixels exceeds the limit of 128x64")
+	if decodes != 0 {
+		t.Errorf("Expected oversized image to be rejected before decoding, got %d decodes", decodes)
+	}
+
+	display, _ = newTestDisplay(t, newTestBuilder().WithMaxImageBytes(info.Size()-1))
+	f, err := os.Open(path)
+	assertNoError(t, err)
+	defer f.Close() //nolint:errcheck
+	assertError(t, display.ShowImageFromReader(f), "larger than the limit")
+
+	display, _ = newTestDisplay(t, newTestBuilder().WithMaxImageBytes(info.Size()).WithMaxImageDimensions(256, 128))
+	assertNoError(t, display.ShowImageFromFile(path))
+	if decodes != 1 {
+		t.Errorf("Expected image within the limits to be decoded, got %d decodes", decodes)
+	}
+
+	_, err = newTestBuilder().WithMaxImageDimensions(-1, 64).Build()
+	assertError(t, err, "invalid maximum image dimensions")
+}
+
+// failingReader returns an error from every read.
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("read past the end of the image")
+}
+
+func TestDisplay_ImageLimits_Streaming(t *testing.T) {
+	data, err := os.ReadFile(writePNG(t, newFilledImage(256, 128, 255)))
+	assertNoError(t, err)
+
+	// Without a byte limit the image is decoded as it is read, so a
+	// stream that fails after the end of the image is still shown.
+	for _, builder := range []*Display{
+		newTestBuilder(),
+		newTestBuilder().WithMaxImageDimensions(256, 128),
+	} {
+		display, mock := newTestDisplay(t, builder)
+		r := io.MultiReader(bytes.NewReader(data), failingReader{})
+		assertNoError(t, display.ShowImageFromReader(r))
+		assertMethodCalled(t, mock, "Draw")
+	}
+}
+
+func TestDisplay_DrawImageCentered(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder())
+
+	assertNoError(t, display.DrawImageCentered(newFilledImage(32, 32, 255)))
+	centered := image.Rect(48, 16, 80, 48)
+	if got := countOn(display.fb, centered); got != 32*32 {
+		t.Errorf("Expected %v to be fully lit, got %d pixels", centered, got)
+	}
+	if got := countOn(display.fb, display.fb.Bounds()); got != 32*32 {
+		t.Errorf("Expected nothing lit outside %v, got %d extra pixels", centered, got-32*32)
+	}
+
+	// A larger image is cropped around its center.
+	large := NewTestImage(256, 128)
+	for y := 32; y < 96; y++ {
+		for x := 64; x < 192; x++ {
+			large.Set(x, y, color.Gray{Y: 255})
+		}
+	}
+	clear(display.fb.Pix)
+	assertNoError(t, display.DrawImageCentered(large))
+	if got := countOn(display.fb, display.fb.Bounds()); got != 128*64 {
+		t.Errorf("Expected the lit center of a large image to fill the display, got %d pixels", got)
+	}
+}
+
+func TestDisplay_ShowRaw(t *testing.T) {
+	display, mock := newTestDisplay(t, newTestBuilder())
+
+	data := make([]byte, 128*64/8)
+	data[0] = 0x01   // top left pixel
+	data[128] = 0x80 // column 0, row 15
+	assertNoError(t, display.ShowRaw(data))
+
+	_, src, _ := mock.LastDrawArgs()
+	if src.At(0, 0) != image1bit.On || src.At(0, 15) != image1bit.On {
+		t.Error("Expected raw bytes to be drawn as page-packed pixels")
+	}
+	if got := countOn(src, src.Bounds()); got != 2 {
+		t.Errorf("Expected 2 lit pixels, got %d", got)
+	}
+
+	assertError(t, display.ShowRaw(data[1:]), "raw frame has 1023 bytes but display needs 1024")
+	if got := mock.CallCount("Draw"); got != 1 {
+		t.Errorf("Expected no draw for a wrong length, got %d draws", got)
+	}
+}
+
+func TestDisplay_Blit(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder())
+	assertNoError(t, display.FillRect(display.fb.Bounds(), true))
+
+	// A 10x10 sprite with a black 4x4 square in the middle and a fully
+	// transparent surround, round-tripped through PNG.
+	sprite := image.NewNRGBA(image.Rect(0, 0, 10, 10))
+	for y := 3; y < 7; y++ {
+		for x := 3; x < 7; x++ {
+			sprite.Set(x, y, color.NRGBA{A: 255})
+		}
+	}
+	f, err := os.Open(writePNG(t, sprite))
+	assertNoError(t, err)
+	defer f.Close() //nolint:errcheck
+	decoded, err := png.Decode(f)
+	assertNoError(t, err)
+
+	at := image.Pt(20, 20)
+	assertNoError(t, display.Blit(decoded, at))
+
+	square := image.Rect(3, 3, 7, 7).Add(at)
+	if got := countOn(display.fb, square); got != 0 {
+		t.Errorf("Expected the opaque square to be drawn dark, got %d lit pixels", got)
+	}
+	bounds := display.fb.Bounds()
+	if got, want := countOn(display.fb, bounds), bounds.Dx()*bounds.Dy()-16; got != want {
+		t.Errorf("Expected the background to show through the transparent pixels, got %d of %d lit", got, want)
+	}
+}
+
+func TestDisplay_ConvertImage(t *testing.T) {
+	display, mock := newTestDisplay(t, newTestBuilder().WithGamma(2.2))
+	img := newFilledImage(40, 20, 200)
+
+	assertNoError(t, display.ShowImage(img))
+	_, want, _ := mock.LastDrawArgs()
+
+	frame, err := display.ConvertImage(img)
+	assertNoError(t, err)
+	assertNoError(t, display.ShowRaw(frame.Pix))
+	_, got, _ := mock.LastDrawArgs()
+
+	if !bytes.Equal(got.(*image1bit.VerticalLSB).Pix, want.(*image1bit.VerticalLSB).Pix) {
+		t.Error("Expected a converted frame shown with ShowRaw to match ShowImage")
+	}
+}
+
+func TestDisplay_DrawBitmapScaled(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder())
+
+	// A 4x4 checkerboard.
+	pattern := image1bit.NewVerticalLSB(image.Rect(0, 0, 4, 4))
+	for y := range 4 {
+		for x := range 4 {
+			pattern.SetBit(x, y, image1bit.Bit((x+y)%2 == 0))
+		}
+	}
+
+	at := image.Pt(10, 5)
+	assertNoError(t, display.DrawBitmapScaled(pattern, at, 3, true))
+	for y := range 4 {
+		for x := range 4 {
+			block := image.Rect(0, 0, 3, 3).Add(at).Add(image.Pt(x*3, y*3))
+			want := 0
+			if (x+y)%2 == 0 {
+				want = 9
+			}
+			if got := countOn(display.fb, block); got != want {
+				t.Errorf("Expected %d lit pixels in block for (%d,%d), got %d", want, x, y, got)
+			}
+		}
+	}
+	if got := countOn(display.fb, display.fb.Bounds()); got != 8*9 {
+		t.Errorf("Expected only the scaled pattern to be drawn, got %d lit pixels", got)
+	}
+
+	assertError(t, display.DrawBitmapScaled(pattern, at, 0, true), "invalid scale")
+}
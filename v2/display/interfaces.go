@@ -1,12 +1,14 @@
 package display
 
 import (
+	"context"
 	"fmt"
 	"image"
 	"io"
 
 	"periph.io/x/conn/v3/i2c"
 	"periph.io/x/conn/v3/i2c/i2creg"
+	"periph.io/x/conn/v3/physic"
 	"periph.io/x/devices/v3/ssd1306"
 	"periph.io/x/host/v3"
 )
@@ -19,35 +21,132 @@ type (
 		Draw(r image.Rectangle, src image.Image, sp image.Point) error
 	}
 
+	// ContrastSetter is implemented by drivers that can change the panel
+	// contrast.
+	ContrastSetter interface {
+		SetContrast(level byte) error
+	}
+
+	// Scroller is implemented by drivers that support the controller's
+	// continuous hardware scrolling.
+	Scroller interface {
+		Scroll(o ssd1306.Orientation, rate ssd1306.FrameRate, startLine, endLine int) error
+		StopScroll() error
+	}
+
+	// Inverter is implemented by drivers that can invert the panel in
+	// hardware.
+	Inverter interface {
+		Invert(blackOnWhite bool) error
+	}
+
+	// ViewerWaiter is implemented by simulated drivers that can tell when
+	// someone is watching the display.
+	ViewerWaiter interface {
+		WaitForViewer(ctx context.Context) error
+	}
+
 	RealSSD1306 struct {
 		busName string
+		opts    ssd1306.Opts
+		offset  int
+		speed   physic.Frequency
+		extVCC  bool
 		bus     i2c.BusCloser
 		dev     *ssd1306.Dev
 	}
 )
 
+// Capability identifies an optional driver feature that Supports can test
+// for.
+type Capability int
+
+const (
+	// CapContrast is provided by drivers implementing ContrastSetter.
+	CapContrast Capability = iota
+	// CapInvert is provided by drivers implementing Inverter.
+	CapInvert
+	// CapScroll is provided by drivers implementing Scroller.
+	CapScroll
+	// CapViewer is provided by drivers implementing ViewerWaiter.
+	CapViewer
+)
+
+// hostInit and openBus are variables so that tests can substitute a fake
+// i2c bus for real hardware. They are only called by acquireBus.
+var (
+	hostInit = host.Init
+	openBus  = i2creg.Open
+)
+
 func NewRealSSD1306(busName string) *RealSSD1306 {
 	return &RealSSD1306{
 		busName: busName,
+		opts:    ssd1306.DefaultOpts,
 	}
 }
 
+// WithOpts replaces the options passed to ssd1306.NewI2C when the device is
+// opened, for controllers such as the SSD1305 or SSD1309 that need a slightly
+// different configuration.
+func (d *RealSSD1306) WithOpts(opts ssd1306.Opts) *RealSSD1306 {
+	d.opts = opts
+	return d
+}
+
+// WithColumnOffset shifts the column address of every write to the panel by
+// n, moving the image n columns to the right.
+func (d *RealSSD1306) WithColumnOffset(n int) *RealSSD1306 {
+	d.offset = n
+	return d
+}
+
+// WithBusFrequency sets the i2c bus clock when the device is opened. Zero
+// leaves the bus at its current speed.
+func (d *RealSSD1306) WithBusFrequency(f physic.Frequency) *RealSSD1306 {
+	d.speed = f
+	return d
+}
+
+// WithExternalVCC configures the controller for panels powered from an
+// external VCC supply, which stay dark unless the internal charge pump is
+// disabled. ssd1306.Opts has no such setting, so Open adjusts the power
+// commands of the periph driver's initialization sequence on the bus.
+func (d *RealSSD1306) WithExternalVCC(external bool) *RealSSD1306 {
+	d.extVCC = external
+	return d
+}
+
+// Open opens the i2c bus and initializes the display. Displays on the same
+// bus share a single open bus, which is closed when the last of them is
+// closed.
 func (d *RealSSD1306) Open() error {
-	// Make sure periph is initialized.
-	if _, err := host.Init(); err != nil {
-		return fmt.Errorf("failed to initialize display: %w", err)
+	b, err := acquireBus(d.busName)
+	if err != nil {
+		return err
 	}
 
-	b, err := i2creg.Open(d.busName)
-	if err != nil {
-		return fmt.Errorf("failed to open i2c bus %s: %w", d.busName, err)
+	if d.speed != 0 {
+		if err := b.SetSpeed(d.speed); err != nil {
+			b.Close() //nolint:errcheck
+			return fmt.Errorf("failed to set i2c bus speed to %s: %w", d.speed, err)
+		}
+	}
+
+	var bus i2c.Bus = b
+	if d.offset != 0 {
+		bus = &columnOffsetBus{Bus: b, offset: d.offset}
+	}
+	if d.extVCC {
+		bus = &externalVCCBus{Bus: bus}
 	}
-	d.bus = b
 
-	dev, err := ssd1306.NewI2C(b, &ssd1306.DefaultOpts)
+	dev, err := ssd1306.NewI2C(bus, &d.opts)
 	if err != nil {
+		b.Close() //nolint:errcheck
 		return fmt.Errorf("failed to initialize ssd1306: %w", err)
 	}
+	d.bus = b
 	d.dev = dev
 	return nil
 }
@@ -63,3 +162,99 @@ func (d *RealSSD1306) Bounds() image.Rectangle {
 func (d *RealSSD1306) Draw(r image.Rectangle, src image.Image, sp image.Point) error {
 	return d.dev.Draw(r, src, sp)
 }
+
+func (d *RealSSD1306) SetContrast(level byte) error {
+	return d.dev.SetContrast(level)
+}
+
+func (d *RealSSD1306) Invert(blackOnWhite bool) error {
+	return d.dev.Invert(blackOnWhite)
+}
+
+func (d *RealSSD1306) Scroll(o ssd1306.Orientation, rate ssd1306.FrameRate, startLine, endLine int) error {
+	return d.dev.Scroll(o, rate, startLine, endLine)
+}
+
+func (d *RealSSD1306) StopScroll() error {
+	return d.dev.StopScroll()
+}
+
+// Device returns the underlying periph ssd1306 device, or nil if the display
+// has not been opened. This is an escape hatch for features this package does
+// not wrap; it is not covered by any compatibility guarantee and may change or
+// go away in a future release.
+func (d *RealSSD1306) Device() *ssd1306.Dev {
+	return d.dev
+}
+
+// columnOffsetBus adds an offset to the column addresses in the commands the
+// periph driver sends. Its initialization sequence selects horizontal
+// addressing and sets a column window of 0 to W-1, which is shifted so that
+// each page of image data starts at the offset instead of wrapping into the
+// next page. Before writing each page it also sends a page start followed by
+// the low and high nibbles of the start column, which controllers that only
+// support page addressing use instead, so those are shifted as well.
+type columnOffsetBus struct {
+	i2c.Bus
+	offset int
+}
+
+const (
+	i2cCommand           = 0x00
+	pageStart            = 0xB0
+	setLowColumn         = 0x00
+	setHighColumn        = 0x10
+	setAddressingMode    = 0x20
+	horizontalAddressing = 0x00
+	setColumnAddress     = 0x21
+)
+
+func (b *columnOffsetBus) Tx(addr uint16, w, r []byte) error {
+	if n := len(w); n >= 4 && w[0] == i2cCommand {
+		w = append([]byte(nil), w...)
+		if w[n-3]&0xF8 == pageStart && w[n-2]&0xF0 == setLowColumn && w[n-1]&0xF0 == setHighColumn {
+			col := int(w[n-2]&0x0F|w[n-1]<<4) + b.offset
+			w[n-2] = setLowColumn | byte(col&0x0F)
+			w[n-1] = setHighColumn | byte(col>>4&0x0F)
+		}
+		for i := 1; i < n-4; i++ {
+			if w[i] == setAddressingMode && w[i+1] == horizontalAddressing && w[i+2] == setColumnAddress {
+				w[i+3] += byte(b.offset)
+				w[i+4] += byte(b.offset)
+				break
+			}
+		}
+	}
+	return b.Bus.Tx(addr, w, r)
+}
+
+// externalVCCBus rewrites the charge pump and pre-charge settings in the
+// commands the periph driver sends, which assume the internal charge pump,
+// to the values for a panel with an external VCC supply.
+type externalVCCBus struct {
+	i2c.Bus
+}
+
+const (
+	setChargePump      = 0x8D
+	chargePumpEnabled  = 0x14
+	chargePumpDisabled = 0x10
+	setPrecharge       = 0xD9
+	prechargeInternal  = 0xF1
+	prechargeExternal  = 0x22
+)
+
+func (b *externalVCCBus) Tx(addr uint16, w, r []byte) error {
+	if len(w) > 0 && w[0] == i2cCommand {
+		w = append([]byte(nil), w...)
+		for i := 1; i < len(w)-1; i++ {
+			switch {
+			case w[i] == setChargePump && w[i+1] == chargePumpEnabled:
+				w[i+1] = chargePumpDisabled
+			case w[i] == setPrecharge && w[i+1] == prechargeInternal:
+				w[i+1] = prechargeExternal
+			}
+		}
+	}
+	return b.Bus.Tx(addr, w, r)
+}
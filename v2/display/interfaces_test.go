@@ -0,0 +1,187 @@
+package display
+
+import (
+	"bytes"
+	"image"
+	"testing"
+
+	"periph.io/x/conn/v3/driver/driverreg"
+	"periph.io/x/conn/v3/i2c"
+	"periph.io/x/conn/v3/i2c/i2ctest"
+	"periph.io/x/conn/v3/physic"
+	"periph.io/x/devices/v3/ssd1306"
+	"periph.io/x/devices/v3/ssd1306/image1bit"
+)
+
+// recordBus is a fake i2c bus that records every transaction.
+type recordBus struct {
+	i2ctest.Record
+	closed bool
+	// speed is the last speed set, and opsAtSpeed the number of
+	// transactions made before it was set.
+	speed      physic.Frequency
+	opsAtSpeed int
+}
+
+func (b *recordBus) Close() error {
+	b.closed = true
+	return nil
+}
+
+func (b *recordBus) SetSpeed(f physic.Frequency) error {
+	b.speed = f
+	b.opsAtSpeed = len(b.Ops)
+	return nil
+}
+
+// withFakeBus makes RealSSD1306 open a recordBus instead of real hardware for
+// the duration of the test.
+func withFakeBus(t *testing.T) *recordBus {
+	t.Helper()
+	bus := &recordBus{}
+
+	origHostInit, origOpenBus, origBuses := hostInit, openBus, buses
+	hostInit = func() (*driverreg.State, error) { return &driverreg.State{}, nil }
+	openBus = func(string) (i2c.BusCloser, error) { return bus, nil }
+	buses = make(map[string]*sharedBus)
+	t.Cleanup(func() {
+		hostInit, openBus, buses = origHostInit, origOpenBus, origBuses
+	})
+
+	return bus
+}
+
+func TestRealSSD1306_Device(t *testing.T) {
+	withFakeBus(t)
+	dev := NewRealSSD1306("fake")
+
+	if dev.Device() != nil {
+		t.Fatal("Expected Device to be nil before Open")
+	}
+
+	assertNoError(t, dev.Open())
+	if dev.Device() == nil {
+		t.Fatal("Expected Device to be set after Open")
+	}
+	assertNoError(t, dev.Close())
+}
+
+func TestDisplay_WithDeviceOpts(t *testing.T) {
+	withFakeBus(t)
+	opts := ssd1306.Opts{W: 128, H: 32, Rotated: true}
+
+	display, err := newTestBuilder().WithLines(2).WithDeviceOpts(opts).Build()
+	assertNoError(t, err)
+	assertNoError(t, display.Init())
+	defer display.Close() //nolint:errcheck
+
+	driver, ok := display.driver.(*RealSSD1306)
+	if !ok {
+		t.Fatalf("Expected a RealSSD1306 driver, got %T", display.driver)
+	}
+	if driver.opts != opts {
+		t.Errorf("Expected opts %+v, got %+v", opts, driver.opts)
+	}
+	if got := driver.Bounds().Dy(); got != 32 {
+		t.Errorf("Expected the device to be opened with height 32, got %d", got)
+	}
+}
+
+func TestDisplay_WithBusFrequency(t *testing.T) {
+	bus := withFakeBus(t)
+
+	display, err := newTestBuilder().WithBusFrequency(400 * physic.KiloHertz).Build()
+	assertNoError(t, err)
+	assertNoError(t, display.Init())
+	defer display.Close() //nolint:errcheck
+
+	if bus.speed != 400*physic.KiloHertz {
+		t.Errorf("Expected the bus speed to be set to 400kHz, got %s", bus.speed)
+	}
+	if bus.opsAtSpeed != 0 {
+		t.Errorf("Expected the speed to be set before the device is initialized, got %d transactions first", bus.opsAtSpeed)
+	}
+
+	_, err = newTestBuilder().WithBusFrequency(-1).Build()
+	assertError(t, err, "invalid bus frequency")
+}
+
+func TestRealSSD1306_WithColumnOffset(t *testing.T) {
+	bus := withFakeBus(t)
+	dev := NewRealSSD1306("fake").WithColumnOffset(2)
+	assertNoError(t, dev.Open())
+	defer dev.Close() //nolint:errcheck
+
+	// The column window set by the initialization sequence is shifted too,
+	// so that horizontal addressing wraps at the end of the shifted page.
+	if len(bus.Ops) == 0 {
+		t.Fatal("Expected the device to be initialized")
+	}
+	width := byte(dev.Bounds().Dx())
+	window := []byte{setAddressingMode, horizontalAddressing, setColumnAddress, 2, width + 1}
+	if init := bus.Ops[0].W; !bytes.Contains(init, window) {
+		t.Errorf("Expected column window 2 to %d, got % x", width+1, init)
+	}
+
+	bus.Ops = nil
+	frame := image1bit.NewVerticalLSB(dev.Bounds())
+	frame.SetBit(0, 0, image1bit.On)
+	assertNoError(t, dev.Draw(frame.Bounds(), frame, image.Point{}))
+
+	var commands [][]byte
+	for _, op := range bus.Ops {
+		if len(op.W) == 4 && op.W[0] == i2cCommand && op.W[1]&0xF8 == pageStart {
+			commands = append(commands, op.W)
+		}
+	}
+	if len(commands) == 0 {
+		t.Fatal("Expected page addressing commands to be sent")
+	}
+	for _, cmd := range commands {
+		if cmd[2] != setLowColumn|2 || cmd[3] != setHighColumn {
+			t.Errorf("Expected column address 2, got command % x", cmd)
+		}
+	}
+}
+
+func TestDisplay_WithExternalVCC(t *testing.T) {
+	// initCommand returns the periph driver's initialization sequence,
+	// the first command sent when the device is opened.
+	initCommand := func(t *testing.T, external bool) []byte {
+		t.Helper()
+		bus := withFakeBus(t)
+
+		display, err := newTestBuilder().WithExternalVCC(external).Build()
+		assertNoError(t, err)
+		assertNoError(t, display.Init())
+		defer display.Close() //nolint:errcheck
+
+		driver, ok := display.driver.(*RealSSD1306)
+		if !ok {
+			t.Fatalf("Expected a RealSSD1306 driver, got %T", display.driver)
+		}
+		if driver.extVCC != external {
+			t.Errorf("Expected external VCC %v, got %v", external, driver.extVCC)
+		}
+		if len(bus.Ops) == 0 {
+			t.Fatal("Expected the device to be initialized")
+		}
+		return bus.Ops[0].W
+	}
+
+	internal := initCommand(t, false)
+	if !bytes.Contains(internal, []byte{setChargePump, chargePumpEnabled}) {
+		t.Errorf("Expected the charge pump to be enabled by default, got % x", internal)
+	}
+
+	external := initCommand(t, true)
+	if !bytes.Contains(external, []byte{setChargePump, chargePumpDisabled}) {
+		t.Errorf("Expected the charge pump to be disabled, got % x", external)
+	}
+	if !bytes.Contains(external, []byte{setPrecharge, prechargeExternal}) {
+		t.Errorf("Expected the external VCC pre-charge period, got % x", external)
+	}
+	if len(external) != len(internal) {
+		t.Errorf("Expected only the power settings to change, got % x", external)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !unix
+
+package display
+
+import (
+	"fmt"
+	"os"
+)
+
+// lockFile is not supported on platforms without flock.
+func lockFile(path string, wait bool) (*os.File, error) {
+	return nil, fmt.Errorf("bus lock files are not supported on this platform")
+}
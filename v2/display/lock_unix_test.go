@@ -0,0 +1,32 @@
+//go:build unix
+
+package display
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDisplay_WithBusLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "i2c-1.lock")
+
+	first, err := newTestBuilder().WithDriver(NewTrackedFakeSSD1306()).
+		WithBusLockFile(path).WithBusLockFailFast(true).Build()
+	assertNoError(t, err)
+	assertNoError(t, first.Init())
+
+	mock := NewTrackedFakeSSD1306()
+	second, err := newTestBuilder().WithDriver(mock).
+		WithBusLockFile(path).WithBusLockFailFast(true).Build()
+	assertNoError(t, err)
+
+	err = second.Init()
+	assertError(t, err, "is held by another process")
+	if mock.WasCalled("Open") {
+		t.Error("expected the bus not to be opened without the lock")
+	}
+
+	assertNoError(t, first.Close())
+	assertNoError(t, second.Init())
+	assertNoError(t, second.Close())
+}
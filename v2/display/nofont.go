@@ -0,0 +1,11 @@
+//go:build nofont
+
+package display
+
+import "golang.org/x/image/font"
+
+// defaultFont returns nil in nofont builds, so that Build requires a font to
+// be configured explicitly.
+func defaultFont() font.Face {
+	return nil
+}
@@ -0,0 +1,21 @@
+//go:build nofont
+
+package display
+
+import (
+	"testing"
+
+	"golang.org/x/image/font/basicfont"
+)
+
+func TestBuild_NoFont(t *testing.T) {
+	t.Setenv("DISPLAY1306_FONT", "")
+
+	if _, err := NewDisplay().Build(); err == nil {
+		t.Error("Expected Build to fail without a font in nofont builds")
+	}
+
+	if _, err := NewDisplay().WithFont(basicfont.Face7x13).Build(); err != nil {
+		t.Errorf("Expected Build to succeed with an explicit font, got %v", err)
+	}
+}
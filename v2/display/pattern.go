@@ -0,0 +1,72 @@
+package display
+
+import (
+	"fmt"
+
+	"periph.io/x/devices/v3/ssd1306/image1bit"
+)
+
+type PatternKind int
+
+const (
+	PatternCheckerboard PatternKind = iota
+	PatternOn
+	PatternOff
+	PatternHorizontalStripes
+	PatternVerticalStripes
+)
+
+// ParsePatternKind converts a pattern name as used on the command line into a
+// PatternKind. "stripes" is an alias for horizontal stripes.
+func ParsePatternKind(name string) (PatternKind, error) {
+	switch name {
+	case "checker", "checkerboard":
+		return PatternCheckerboard, nil
+	case "on":
+		return PatternOn, nil
+	case "off":
+		return PatternOff, nil
+	case "stripes", "hstripes":
+		return PatternHorizontalStripes, nil
+	case "vstripes":
+		return PatternVerticalStripes, nil
+	}
+	return 0, fmt.Errorf("unknown test pattern %q", name)
+}
+
+// TestPattern sends a test pattern straight to the display, bypassing the
+// text buffer and framebuffer. The next call to Update replaces it.
+func (d *Display) TestPattern(kind PatternKind) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	var lit func(x, y int) bool
+	switch kind {
+	case PatternCheckerboard:
+		lit = func(x, y int) bool { return (x+y)%2 == 0 }
+	case PatternOn:
+		lit = func(x, y int) bool { return true }
+	case PatternOff:
+		lit = func(x, y int) bool { return false }
+	case PatternHorizontalStripes:
+		lit = func(x, y int) bool { return y%2 == 0 }
+	case PatternVerticalStripes:
+		lit = func(x, y int) bool { return x%2 == 0 }
+	default:
+		return fmt.Errorf("unknown test pattern %d", kind)
+	}
+
+	bounds := d.driver.Bounds()
+	img := image1bit.NewVerticalLSB(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			img.SetBit(x, y, image1bit.Bit(lit(x, y)))
+		}
+	}
+
+	if err := d.draw(img); err != nil {
+		return fmt.Errorf("failed to draw test pattern: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package display
+
+import (
+	"testing"
+
+	"periph.io/x/devices/v3/ssd1306/image1bit"
+)
+
+func TestDisplay_TestPattern(t *testing.T) {
+	display, mock := newTestDisplay(t, newTestBuilder())
+
+	assertNoError(t, display.TestPattern(PatternCheckerboard))
+	_, src, _ := mock.LastDrawArgs()
+	img := src.(*image1bit.VerticalLSB)
+	for y := range 64 {
+		for x := range 128 {
+			if want := image1bit.Bit((x+y)%2 == 0); img.BitAt(x, y) != want {
+				t.Fatalf("Expected checkerboard pixel (%d,%d) to be %v", x, y, want)
+			}
+		}
+	}
+
+	assertNoError(t, display.TestPattern(PatternOn))
+	_, src, _ = mock.LastDrawArgs()
+	if got := countOn(src, src.Bounds()); got != 128*64 {
+		t.Errorf("Expected every pixel lit, got %d", got)
+	}
+
+	assertError(t, display.TestPattern(PatternKind(99)), "unknown test pattern")
+}
+
+func TestParsePatternKind(t *testing.T) {
+	for name, want := range map[string]PatternKind{
+		"checker": PatternCheckerboard,
+		"on":      PatternOn,
+		"off":     PatternOff,
+		"stripes": PatternHorizontalStripes,
+	} {
+		got, err := ParsePatternKind(name)
+		assertNoError(t, err)
+		if got != want {
+			t.Errorf("Expected %q to parse as %d, got %d", name, want, got)
+		}
+	}
+
+	_, err := ParsePatternKind("plaid")
+	assertError(t, err, "unknown test pattern")
+}
@@ -0,0 +1,46 @@
+package display
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/skip2/go-qrcode"
+	"periph.io/x/devices/v3/ssd1306/image1bit"
+)
+
+// DrawQRCodeAt draws a QR code encoding data into the framebuffer with its
+// top left corner at the given point. Each module of the code is drawn as a
+// moduleSize x moduleSize block. Light modules and the surrounding quiet zone
+// are lit so that the code scans like a regular dark-on-light code.
+func (d *Display) DrawQRCodeAt(data string, at image.Point, moduleSize int) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	if moduleSize <= 0 {
+		return fmt.Errorf("invalid module size %d", moduleSize)
+	}
+
+	code, err := qrcode.New(data, qrcode.Medium)
+	if err != nil {
+		return fmt.Errorf("failed to encode qr code: %w", err)
+	}
+
+	bitmap := code.Bitmap()
+	size := len(bitmap) * moduleSize
+	area := image.Rectangle{Min: at, Max: at.Add(image.Pt(size, size))}
+	if bounds := d.fb.Bounds(); !area.In(bounds) {
+		return fmt.Errorf("qr code of %dx%d pixels at %v does not fit in display bounds %v", size, size, at, bounds)
+	}
+
+	for row, modules := range bitmap {
+		for col, dark := range modules {
+			module := image.Rect(0, 0, moduleSize, moduleSize).
+				Add(at).
+				Add(image.Pt(col*moduleSize, row*moduleSize))
+			d.fillFramebuffer(module, image1bit.Bit(!dark))
+		}
+	}
+
+	return d.changed()
+}
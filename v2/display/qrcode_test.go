@@ -0,0 +1,69 @@
+package display
+
+import (
+	"image"
+	"testing"
+)
+
+func TestDisplay_DrawQRCodeAt(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder())
+
+	err := display.DrawQRCodeAt("display1306", image.Pt(64, 0), 2)
+	assertNoError(t, err)
+
+	bounds := display.fb.Bounds()
+	if got := countOn(display.fb, image.Rect(0, 0, 64, bounds.Max.Y)); got != 0 {
+		t.Errorf("Expected left half to be left for text, found %d lit pixels", got)
+	}
+
+	right := image.Rect(64, 0, bounds.Max.X, bounds.Max.Y)
+	if got := countOn(display.fb, right); got == 0 {
+		t.Error("Expected qr code to be drawn in the right half")
+	}
+
+	// The quiet zone around the code is lit.
+	if got := countOn(display.fb, image.Rect(64, 0, 66, 2)); got != 4 {
+		t.Errorf("Expected top left module to be lit, got %d lit pixels", got)
+	}
+}
+
+func TestDisplay_DrawQRCodeAt_Errors(t *testing.T) {
+	display, _ := newTestDisplay(t, newTestBuilder())
+
+	tests := []struct {
+		name        string
+		at          image.Point
+		moduleSize  int
+		errorSubstr string
+	}{
+		{
+			name:        "does not fit at module size",
+			at:          image.Pt(0, 0),
+			moduleSize:  3,
+			errorSubstr: "does not fit",
+		},
+		{
+			name:        "does not fit from origin",
+			at:          image.Pt(100, 0),
+			moduleSize:  1,
+			errorSubstr: "does not fit",
+		},
+		{
+			name:        "invalid module size",
+			at:          image.Pt(0, 0),
+			moduleSize:  0,
+			errorSubstr: "invalid module size",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := display.DrawQRCodeAt("display1306", tt.at, tt.moduleSize)
+			assertError(t, err, tt.errorSubstr)
+		})
+	}
+
+	if got := countOn(display.fb, display.fb.Bounds()); got != 0 {
+		t.Errorf("Expected nothing to be drawn on error, found %d lit pixels", got)
+	}
+}
@@ -0,0 +1,90 @@
+package display
+
+import (
+	"fmt"
+	"image"
+
+	"periph.io/x/devices/v3/ssd1306/image1bit"
+)
+
+// sevenSegments lists the lit segments of each supported character, using
+// the usual a-g naming: a is the top, then clockwise round to f, and g is the
+// middle bar.
+var sevenSegments = map[rune]string{
+	'0': "abcdef",
+	'1': "bc",
+	'2': "abdeg",
+	'3': "abcdg",
+	'4': "bcfg",
+	'5': "acdfg",
+	'6': "acdefg",
+	'7': "abc",
+	'8': "abcdefg",
+	'9': "abcdfg",
+	'-': "g",
+	' ': "",
+}
+
+// DrawSevenSegment draws text into the framebuffer as seven-segment digits,
+// each digitWidth x digitHeight pixels, with the top left corner of the first
+// digit at the given point. Digits, minus, space and colon are supported.
+func (d *Display) DrawSevenSegment(at image.Point, digitWidth, digitHeight int, text string) error {
+	if !d.initialized {
+		return fmt.Errorf("driver has not been initialized")
+	}
+
+	if digitWidth < 3 || digitHeight < 5 {
+		return fmt.Errorf("digits of %dx%d pixels are too small", digitWidth, digitHeight)
+	}
+
+	for _, r := range text {
+		if _, ok := sevenSegments[r]; !ok && r != ':' {
+			return fmt.Errorf("unsupported seven-segment character %q", r)
+		}
+	}
+
+	thickness := max(1, min(digitWidth, digitHeight)/6)
+	gap := max(1, digitWidth/5)
+
+	x := at.X
+	for _, r := range text {
+		if r == ':' {
+			dot := image.Rect(0, 0, thickness, thickness).Add(image.Pt(x+thickness, at.Y))
+			d.fillFramebuffer(dot.Add(image.Pt(0, digitHeight/3)), image1bit.On)
+			d.fillFramebuffer(dot.Add(image.Pt(0, digitHeight*2/3)), image1bit.On)
+			x += 3*thickness + gap
+			continue
+		}
+
+		for _, segment := range sevenSegments[r] {
+			rect := segmentRect(segment, digitWidth, digitHeight, thickness)
+			d.fillFramebuffer(rect.Add(image.Pt(x, at.Y)), image1bit.On)
+		}
+		x += digitWidth + gap
+	}
+
+	return d.changed()
+}
+
+// segmentRect returns the rectangle covered by a segment of a digit of the
+// given size, relative to the top left corner of the digit.
+func segmentRect(segment rune, w, h, t int) image.Rectangle {
+	mid := h / 2
+	switch segment {
+	case 'a':
+		return image.Rect(t, 0, w-t, t)
+	case 'b':
+		return image.Rect(w-t, t, w, mid)
+	case 'c':
+		return image.Rect(w-t, mid, w, h-t)
+	case 'd':
+		return image.Rect(t, h-t, w-t, h)
+	case 'e':
+		return image.Rect(0, mid, t, h-t)
+	case 'f':
+		return image.Rect(0, t, t, mid)
+	case 'g':
+		return image.Rect(t, mid-t/2, w-t, mid-t/2+t)
+	}
+	return image.Rectangle{}
+}
@@ -0,0 +1,47 @@
+package display
+
+import (
+	"image"
+	"testing"
+)
+
+func TestDisplay_DrawSevenSegment(t *testing.T) {
+	const w, h = 12, 20
+	display, _ := newTestDisplay(t, newTestBuilder())
+	assertNoError(t, display.DrawSevenSegment(image.Pt(0, 0), w, h, "12:34"))
+
+	// Thickness is 2 and the gap between characters is 2, so the digits
+	// start at 0, 14, 36 and 50 with the colon at 28.
+	lit := func(x int, segment rune) bool {
+		r := segmentRect(segment, w, h, 2).Add(image.Pt(x, 0))
+		return countOn(display.fb, r) == r.Dx()*r.Dy()
+	}
+
+	tests := []struct {
+		name    string
+		x       int
+		on, off string
+	}{
+		{"1", 0, "bc", "adefg"},
+		{"2", 14, "abdeg", "cf"},
+		{"4", 50, "bcfg", "ade"},
+	}
+	for _, tt := range tests {
+		for _, s := range tt.on {
+			if !lit(tt.x, s) {
+				t.Errorf("Expected segment %c of %q to be lit", s, tt.name)
+			}
+		}
+		for _, s := range tt.off {
+			if countOn(display.fb, segmentRect(s, w, h, 2).Add(image.Pt(tt.x, 0))) != 0 {
+				t.Errorf("Expected segment %c of %q to be dark", s, tt.name)
+			}
+		}
+	}
+
+	if countOn(display.fb, image.Rect(28, 0, 34, h)) != 8 {
+		t.Error("Expected two 2x2 colon dots")
+	}
+
+	assertError(t, display.DrawSevenSegment(image.Pt(0, 0), w, h, "1.5"), `unsupported seven-segment character '.'`)
+}
@@ -0,0 +1,76 @@
+package display
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+
+	"periph.io/x/devices/v3/ssd1306/image1bit"
+)
+
+// TermSSD1306 is an SSD1306 that renders each frame to a terminal, using
+// Unicode half-block characters so that each character cell shows two
+// vertically adjacent pixels.
+type TermSSD1306 struct {
+	out   io.Writer
+	frame *image1bit.VerticalLSB
+	drawn bool
+}
+
+func NewTermSSD1306(w, h int, out io.Writer) *TermSSD1306 {
+	return &TermSSD1306{
+		out:   out,
+		frame: image1bit.NewVerticalLSB(image.Rect(0, 0, w, h)),
+	}
+}
+
+func (t *TermSSD1306) Open() error {
+	return nil
+}
+
+func (t *TermSSD1306) Close() error {
+	return nil
+}
+
+func (t *TermSSD1306) Bounds() image.Rectangle {
+	return t.frame.Bounds()
+}
+
+// Draw updates the frame and redraws it in the terminal. After the first
+// frame, the cursor is moved back up so that each frame overwrites the last.
+func (t *TermSSD1306) Draw(r image.Rectangle, src image.Image, sp image.Point) error {
+	draw.Draw(t.frame, r, src, sp, draw.Src)
+
+	bounds := t.frame.Bounds()
+	rows := (bounds.Dy() + 1) / 2
+
+	var buf bytes.Buffer
+	if t.drawn {
+		fmt.Fprintf(&buf, "\x1b[%dA\r", rows)
+	}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += 2 {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			top := bool(t.frame.BitAt(x, y))
+			bottom := y+1 < bounds.Max.Y && bool(t.frame.BitAt(x, y+1))
+			switch {
+			case top && bottom:
+				buf.WriteRune('█')
+			case top:
+				buf.WriteRune('▀')
+			case bottom:
+				buf.WriteRune('▄')
+			default:
+				buf.WriteByte(' ')
+			}
+		}
+		buf.WriteByte('\n')
+	}
+
+	if _, err := t.out.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write frame to terminal: %w", err)
+	}
+	t.drawn = true
+	return nil
+}
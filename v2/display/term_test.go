@@ -0,0 +1,40 @@
+package display
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTermSSD1306_Draw(t *testing.T) {
+	var out bytes.Buffer
+	driver := NewTermSSD1306(128, 64, &out)
+	display, err := newTestBuilder().WithDriver(driver).Build()
+	assertNoError(t, err)
+	assertNoError(t, display.Init())
+
+	assertNoError(t, display.DrawLine(0, 0, 0, 1, true))
+	assertNoError(t, display.DrawLine(1, 1, 1, 1, true))
+	assertNoError(t, display.Update())
+
+	rows := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(rows) != 32 {
+		t.Fatalf("Expected 32 rows for 64 pixels, got %d", len(rows))
+	}
+	for i, row := range rows {
+		if n := utf8.RuneCountInString(row); n != 128 {
+			t.Fatalf("Expected 128 characters in row %d, got %d", i, n)
+		}
+	}
+	if !strings.HasPrefix(rows[0], "█▄") {
+		t.Errorf("Expected row 0 to start with full and lower half blocks, got %q", string([]rune(rows[0])[:2]))
+	}
+
+	// Later frames move the cursor back to the top first.
+	out.Reset()
+	assertNoError(t, display.Update())
+	if !strings.HasPrefix(out.String(), "\x1b[32A\r") {
+		t.Errorf("Expected cursor to move up 32 rows, got %q", out.String()[:8])
+	}
+}
@@ -4,6 +4,7 @@ go 1.23.4
 
 require (
 	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 	github.com/spf13/pflag v1.0.7
 	golang.org/x/image v0.28.0
 	periph.io/x/conn/v3 v3.7.2